@@ -0,0 +1,16 @@
+-- Миграция 010: Колонка updated_at для auth_tokens
+-- Автор: System
+-- Дата: 2026-10-18
+-- Описание: триггер trigger_update_auth_tokens_updated_at из миграции 005 пишет в NEW.updated_at,
+-- но колонки не было, поэтому любой UPDATE (last_used_at, отзыв токена) падал.
+-- В колонке token теперь хранится SHA-256 хэш токена, а не сам токен
+
+ALTER TABLE auth_tokens
+ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_auth_tokens_active ON auth_tokens(user_id, is_active);
+
+DO $$
+BEGIN
+    RAISE NOTICE '✅ Добавлена колонка auth_tokens.updated_at';
+END $$;
@@ -0,0 +1,22 @@
+-- Миграция 011: Семейства refresh токенов
+-- Автор: System
+-- Дата: 2026-10-18
+-- Описание: refresh токен ротируется при каждом использовании; все токены одной сессии
+-- объединены family_id. Повторное предъявление уже использованного refresh токена
+-- (refresh_used_at IS NOT NULL) означает его кражу - отзывается все семейство
+
+ALTER TABLE auth_tokens
+ADD COLUMN IF NOT EXISTS family_id UUID,
+ADD COLUMN IF NOT EXISTS refresh_used_at TIMESTAMP WITH TIME ZONE;
+
+UPDATE auth_tokens SET family_id = id WHERE family_id IS NULL;
+
+ALTER TABLE auth_tokens ALTER COLUMN family_id SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_auth_tokens_family_id ON auth_tokens(family_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_auth_tokens_refresh_token ON auth_tokens(refresh_token) WHERE refresh_token IS NOT NULL;
+
+DO $$
+BEGIN
+    RAISE NOTICE '✅ Добавлены семейства refresh токенов в auth_tokens';
+END $$;
@@ -0,0 +1,60 @@
+-- Миграция 012: Роли и права доступа (RBAC)
+-- Автор: System
+-- Дата: 2026-10-18
+-- Описание: users.roles хранит имена ролей, права ролей задаются в role_permissions.
+-- Право имеет вид "ресурс:действие"; "*" и "ресурс:*" покрывают все права (ресурса)
+
+CREATE TABLE IF NOT EXISTS roles (
+    name VARCHAR(64) PRIMARY KEY,
+    description TEXT DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role_name VARCHAR(64) NOT NULL REFERENCES roles(name) ON DELETE CASCADE ON UPDATE CASCADE,
+    permission VARCHAR(128) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (role_name, permission)
+);
+
+-- Триггер для обновления updated_at
+CREATE OR REPLACE FUNCTION update_roles_updated_at()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.updated_at = CURRENT_TIMESTAMP;
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+DROP TRIGGER IF EXISTS trigger_update_roles_updated_at ON roles;
+CREATE TRIGGER trigger_update_roles_updated_at
+    BEFORE UPDATE ON roles
+    FOR EACH ROW
+    EXECUTE FUNCTION update_roles_updated_at();
+
+-- Встроенные роли
+INSERT INTO roles (name, description) VALUES
+    ('admin', 'Полный доступ'),
+    ('premium', 'Пользователь с платной подпиской'),
+    ('user', 'Пользователь')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_name, permission) VALUES
+    ('admin', '*'),
+    ('premium', 'streams:publish'),
+    ('premium', 'streams:watch'),
+    ('premium', 'streams:record'),
+    ('user', 'streams:publish'),
+    ('user', 'streams:watch')
+ON CONFLICT DO NOTHING;
+
+-- Роли, уже назначенные пользователям, регистрируются без прав
+INSERT INTO roles (name)
+SELECT DISTINCT unnest(roles) FROM users WHERE roles IS NOT NULL
+ON CONFLICT (name) DO NOTHING;
+
+DO $$
+BEGIN
+    RAISE NOTICE '✅ Таблицы roles и role_permissions созданы';
+END $$;
@@ -0,0 +1,54 @@
+-- Миграция 013: Сервисные аккаунты для межсервисных вызовов
+-- Автор: System
+-- Дата: 2026-10-18
+-- Описание: api-gateway, video-service и другие внутренние сервисы получают токен
+-- по client_id/client_secret (client credentials) или аутентифицируются клиентским
+-- сертификатом mTLS (tls_identity - URI SAN или CN сертификата). Имя аккаунта
+-- используется в политиках доступа к RPC
+
+CREATE TABLE IF NOT EXISTS service_accounts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(64) UNIQUE NOT NULL,
+    description TEXT DEFAULT '',
+
+    client_id VARCHAR(64) UNIQUE NOT NULL,
+    client_secret_hash VARCHAR(255) NOT NULL, -- SHA-256 секрета
+    tls_identity VARCHAR(255) UNIQUE,
+
+    is_active BOOLEAN DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE TABLE IF NOT EXISTS service_tokens (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    service_account_id UUID NOT NULL REFERENCES service_accounts(id) ON DELETE CASCADE,
+    token VARCHAR(255) UNIQUE NOT NULL, -- SHA-256 токена
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Индексы
+CREATE INDEX IF NOT EXISTS idx_service_tokens_account ON service_tokens(service_account_id);
+CREATE INDEX IF NOT EXISTS idx_service_tokens_expires_at ON service_tokens(expires_at);
+
+-- Триггер для обновления updated_at
+CREATE OR REPLACE FUNCTION update_service_accounts_updated_at()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.updated_at = CURRENT_TIMESTAMP;
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+DROP TRIGGER IF EXISTS trigger_update_service_accounts_updated_at ON service_accounts;
+CREATE TRIGGER trigger_update_service_accounts_updated_at
+    BEFORE UPDATE ON service_accounts
+    FOR EACH ROW
+    EXECUTE FUNCTION update_service_accounts_updated_at();
+
+DO $$
+BEGIN
+    RAISE NOTICE '✅ Таблицы service_accounts и service_tokens созданы';
+END $$;
@@ -0,0 +1,21 @@
+-- Миграция 014: API ключи клиентов стриминга в auth_tokens
+-- Автор: System
+-- Дата: 2026-10-18
+-- Описание: API ключ - запись auth_tokens с token_type = 'api_key'. В token хранится SHA-256
+-- ключа, key_prefix - видимое начало ключа, по которому пользователь узнает его в списке.
+-- scopes ограничивают, для чего ключ принимается (stream:publish, stream:view).
+-- Ключ, выданный вместе с конфигурацией стриминга, привязан к клиенту через client_info->>'client_id'
+
+ALTER TABLE auth_tokens
+ADD COLUMN IF NOT EXISTS name VARCHAR(100),
+ADD COLUMN IF NOT EXISTS key_prefix VARCHAR(32),
+ADD COLUMN IF NOT EXISTS scopes TEXT[] DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS idx_auth_tokens_api_keys
+    ON auth_tokens(user_id, created_at DESC)
+    WHERE token_type = 'api_key';
+
+DO $$
+BEGIN
+    RAISE NOTICE '✅ Добавлены колонки API ключей в auth_tokens';
+END $$;
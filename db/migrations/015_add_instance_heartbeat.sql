@@ -0,0 +1,20 @@
+-- Миграция 015: Heartbeat инстансов video-service
+-- Автор: System
+-- Дата: 2026-10-18
+-- Описание: инстанс периодически сообщает свою нагрузку (RPC ReportInstanceLoad), она
+-- перезаписывает current_load. Между heartbeat'ами user-service сам увеличивает current_load
+-- при назначении клиента (условный UPDATE current_load < max_capacity), поэтому
+-- параллельные клиенты не переполняют инстанс
+
+ALTER TABLE video_service_instances
+ADD COLUMN IF NOT EXISTS last_heartbeat TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE video_service_instances
+DROP CONSTRAINT IF EXISTS video_service_instances_load_check;
+ALTER TABLE video_service_instances
+ADD CONSTRAINT video_service_instances_load_check CHECK (current_load >= 0);
+
+DO $$
+BEGIN
+    RAISE NOTICE '✅ Добавлен heartbeat инстансов video-service';
+END $$;
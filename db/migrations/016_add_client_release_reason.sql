@@ -0,0 +1,19 @@
+-- Миграция 016: Освобождение назначений клиентов
+-- Автор: System
+-- Дата: 2026-10-18
+-- Описание: клиент подтверждает сессию heartbeat'ом (last_seen) и освобождает место на
+-- инстансе при завершении потока; назначения клиентов, переставших присылать heartbeat,
+-- снимает фоновый reaper. Причина и время освобождения сохраняются в user_clients
+
+ALTER TABLE user_clients
+ADD COLUMN IF NOT EXISTS release_reason VARCHAR(32),
+ADD COLUMN IF NOT EXISTS released_at TIMESTAMP WITH TIME ZONE;
+
+-- Поиск назначенных клиентов без heartbeat
+CREATE INDEX IF NOT EXISTS idx_user_clients_assigned_last_seen
+    ON user_clients(last_seen) WHERE assigned_instance_id IS NOT NULL;
+
+DO $$
+BEGIN
+    RAISE NOTICE '✅ Добавлены причина и время освобождения назначений клиентов';
+END $$;
@@ -0,0 +1,22 @@
+-- Миграция 017: Жизненный цикл инстансов video-service
+-- Автор: System
+-- Дата: 2026-10-18
+-- Описание: состояние инстанса для выкатки video-service:
+--   active      - принимает новых клиентов
+--   draining    - новых клиентов не принимает, назначенные остаются до отключения или drain_deadline
+--   maintenance - выведен из роутинга, назначенные клиенты перенаправляются
+--   retired     - выведен навсегда
+-- Допустимые переходы проверяет user-service (models.InstanceStateTransitions)
+
+ALTER TABLE video_service_instances
+ADD COLUMN IF NOT EXISTS state VARCHAR(20) NOT NULL DEFAULT 'active'
+    CHECK (state IN ('active', 'draining', 'maintenance', 'retired')),
+ADD COLUMN IF NOT EXISTS state_changed_at TIMESTAMP WITH TIME ZONE,
+ADD COLUMN IF NOT EXISTS drain_deadline TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_video_service_instances_state ON video_service_instances(state);
+
+DO $$
+BEGIN
+    RAISE NOTICE '✅ Добавлено состояние жизненного цикла инстансов video-service';
+END $$;
@@ -0,0 +1,20 @@
+-- Миграция 018: Нагрузка, сообщенная инстансом video-service
+-- Автор: System
+-- Дата: 2026-10-18
+-- Описание: heartbeat инстанса (RPC ReportInstanceLoad) больше не перезаписывает current_load:
+-- current_load - счетчик клиентов, назначенных user-service (ReserveSlot/ReleaseSlot), и его
+-- перезапись теряла места, занятые между heartbeat'ами. Сообщенная нагрузка хранится
+-- отдельно в reported_load; NULL - инстанс еще не присылал heartbeat
+
+ALTER TABLE video_service_instances
+ADD COLUMN IF NOT EXISTS reported_load INT;
+
+ALTER TABLE video_service_instances
+DROP CONSTRAINT IF EXISTS video_service_instances_reported_load_check;
+ALTER TABLE video_service_instances
+ADD CONSTRAINT video_service_instances_reported_load_check CHECK (reported_load >= 0);
+
+DO $$
+BEGIN
+    RAISE NOTICE '✅ Добавлена сообщенная нагрузка инстансов video-service';
+END $$;
@@ -0,0 +1,14 @@
+-- Миграция 019: Кодеки клиента
+-- Автор: System
+-- Дата: 2026-10-18
+-- Описание: кодеки, которые клиент сообщил при назначении инстанса. По ним клиент
+-- переносится при выводе инстанса (DrainInstance) только на совместимый инстанс.
+-- NULL или пустой массив - клиент принимает любой кодек
+
+ALTER TABLE user_clients
+ADD COLUMN IF NOT EXISTS codecs TEXT[];
+
+DO $$
+BEGIN
+    RAISE NOTICE '✅ Добавлены кодеки клиентов';
+END $$;
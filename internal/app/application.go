@@ -2,39 +2,113 @@ package app
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
+	"github.com/redis/go-redis/v9"
+
+	"github.com/haqury/user-service/internal/auth"
 	"github.com/haqury/user-service/internal/config"
+	"github.com/haqury/user-service/internal/handler"
+	"github.com/haqury/user-service/internal/healthcheck"
+	"github.com/haqury/user-service/internal/reaper"
 	"github.com/haqury/user-service/internal/repository"
 	"github.com/haqury/user-service/internal/service"
+	"github.com/haqury/user-service/internal/tlsutil"
 )
 
 // Application - основная структура приложения
 type Application struct {
 	Config   *config.Config
+	DB       *sql.DB
+	Redis    *redis.Client
+	TLS      *tlsutil.Reloader // nil, если TLS выключен
 	Services *service.Services
 	Repos    *repository.Repositories
+
+	// TrustedProxies - разобранный auth.throttle.trusted_proxies
+	TrustedProxies []*net.IPNet
+
+	// HealthChecker - проверка здоровья инстансов video-service (nil, если выключена)
+	HealthChecker *healthcheck.Checker
+	// SessionReaper - освобождение назначений отключившихся клиентов (nil, если выключено)
+	SessionReaper *reaper.Reaper
 }
 
-// New создает новое приложение
-func New(c *config.Config) *Application {
+// New создает новое приложение: подключается к БД и собирает репозитории и сервисы
+func New(c *config.Config) (*Application, error) {
+	// Список прокси разбирается до подключений, чтобы ошибка конфигурации не оставляла
+	// открытых ресурсов и не всплывала после занятия порта
+	trustedProxies, err := handler.ParseTrustedProxies(c.Auth.Throttle.TrustedProxies)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse trusted proxies: %w", err)
+	}
+
+	// Подключаемся к базе данных
+	db, err := openDatabase(context.Background(), c.Database)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	app := &Application{
+		Config:         c,
+		DB:             db,
+		TrustedProxies: trustedProxies,
+	}
+
+	// Redis нужен только для общих между репликами счетчиков
+	if needsRedis(c) {
+		app.Redis, err = openRedis(context.Background(), c.Redis)
+		if err != nil {
+			app.Close()
+			return nil, fmt.Errorf("failed to connect to redis: %w", err)
+		}
+	}
+
+	// Сертификаты загружаются при старте, чтобы ошибка в путях не всплыла на первом handshake
+	if c.TLS.Enabled {
+		app.TLS, err = tlsutil.NewReloader(c.TLS)
+		if err != nil {
+			app.Close()
+			return nil, fmt.Errorf("failed to load tls certificates: %w", err)
+		}
+	}
+
 	// Инициализируем репозитории
-	repos := repository.New()
+	app.Repos = repository.NewWithDB(db)
 
 	// Инициализируем сервисы
-	services := service.New(repos)
+	app.Services, err = service.New(app.Repos, c, app.Redis)
+	if err != nil {
+		app.Close()
+		return nil, fmt.Errorf("failed to create services: %w", err)
+	}
 
-	return &Application{
-		Config:   c,
-		Services: services,
-		Repos:    repos,
+	if c.Routing.HealthCheck.Enabled {
+		app.HealthChecker, err = healthcheck.NewChecker(app.Repos.VideoServiceInstance, c.Routing.HealthCheck)
+		if err != nil {
+			app.Close()
+			return nil, fmt.Errorf("failed to create health checker: %w", err)
+		}
+	}
+
+	if c.Routing.Sessions.ReapInterval > 0 {
+		app.SessionReaper, err = reaper.NewReaper(app.Repos.UserClient, c.Routing.Sessions)
+		if err != nil {
+			app.Close()
+			return nil, fmt.Errorf("failed to create session reaper: %w", err)
+		}
 	}
+
+	return app, nil
 }
 
 // NewWithConfig создает приложение с конфигурацией из файла или env
@@ -44,14 +118,54 @@ func NewWithConfig(cfgPath string, grpcPort string) (*Application, error) {
 		return nil, fmt.Errorf("failed to create config: %w", err)
 	}
 
-	return New(c), nil
+	return New(c)
 }
 
 // Run запускает приложение (gRPC + HTTP Gateway)
 func (app *Application) Run() error {
+	defer app.Close()
+
 	// Канал для ошибок
 	errChan := make(chan error, 2)
 
+	// Фоновые задачи, которые нужно дождаться при остановке (ожидание после cancel)
+	var background sync.WaitGroup
+	defer background.Wait()
+
+	// Контекст фоновых задач, отменяется при остановке
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Перечитываем ключи подписи JWT для плановой ротации
+	if app.Services.Keys != nil {
+		go app.Services.Keys.Run(ctx, app.Config.JWT.KeysReloadInterval, func() ([]*auth.RingKey, error) {
+			return auth.RingKeysFromConfig(app.Config.JWT)
+		})
+	}
+
+	// Перечитываем сертификаты при их замене без рестарта
+	if app.TLS != nil {
+		go app.TLS.Run(ctx, app.Config.TLS.ReloadInterval)
+	}
+
+	// Проверяем здоровье инстансов video-service, по которым роутятся клиенты
+	if app.HealthChecker != nil {
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			app.HealthChecker.Run(ctx)
+		}()
+	}
+
+	// Освобождаем инстансы, назначенные клиентам без heartbeat
+	if app.SessionReaper != nil {
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			app.SessionReaper.Run(ctx)
+		}()
+	}
+
 	// Адреса серверов
 	grpcAddr := ":" + app.Config.GRPCPort
 	httpAddr := ":" + app.Config.HTTPPort
@@ -70,8 +184,7 @@ func (app *Application) Run() error {
 	// Запускаем HTTP Gateway сервер в горутине
 	go func() {
 		log.Printf("Starting HTTP Gateway on port %s", app.Config.HTTPPort)
-		ctx := context.Background()
-		if err := StartGatewayServer(ctx, "localhost"+grpcAddr, httpAddr); err != nil && err != http.ErrServerClosed {
+		if err := StartGatewayServer(ctx, app, "localhost"+grpcAddr, httpAddr); err != nil && err != http.ErrServerClosed {
 			errChan <- fmt.Errorf("HTTP gateway error: %w", err)
 		}
 	}()
@@ -92,8 +205,30 @@ func (app *Application) Run() error {
 	return nil
 }
 
+// Close освобождает ресурсы приложения (пулы соединений с БД и Redis)
+func (app *Application) Close() error {
+	if app.Redis != nil {
+		if err := app.Redis.Close(); err != nil {
+			log.Printf("Failed to close redis client: %v", err)
+		}
+	}
+
+	if app.DB == nil {
+		return nil
+	}
+
+	log.Println("Closing database connections...")
+	return app.DB.Close()
+}
+
 // HealthCheck - проверка здоровья приложения
 func (app *Application) HealthCheck() bool {
-	// Проверяем подключение к БД, кэшу и т.д.
-	return true
+	if app.DB == nil {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	return app.DB.PingContext(ctx) == nil
 }
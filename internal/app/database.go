@@ -0,0 +1,61 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/haqury/user-service/internal/config"
+
+	_ "github.com/uptrace/bun/driver/pgdriver" // PostgreSQL драйвер
+)
+
+// openDatabase открывает пул соединений и дожидается доступности БД.
+// Ping повторяется ConnectRetries раз с экспоненциальным backoff
+func openDatabase(ctx context.Context, c config.DatabaseConnConfig) (*sql.DB, error) {
+	db, err := sql.Open("pg", c.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	db.SetMaxOpenConns(c.MaxOpenConns)
+	db.SetMaxIdleConns(c.MaxIdleConns)
+	db.SetConnMaxLifetime(c.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(c.ConnMaxIdleTime)
+
+	backoff := c.ConnectBackoff
+	if backoff <= 0 {
+		backoff = time.Second
+	}
+
+	for attempt := 0; ; attempt++ {
+		pingCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		err = db.PingContext(pingCtx)
+		cancel()
+		if err == nil {
+			return db, nil
+		}
+
+		if attempt >= c.ConnectRetries {
+			db.Close()
+			return nil, fmt.Errorf("failed to ping database after %d attempts: %w", attempt+1, err)
+		}
+
+		log.Printf("Database is not ready (attempt %d/%d): %v, retrying in %s",
+			attempt+1, c.ConnectRetries+1, err, backoff)
+
+		select {
+		case <-ctx.Done():
+			db.Close()
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if c.MaxConnectBackoff > 0 && backoff > c.MaxConnectBackoff {
+			backoff = c.MaxConnectBackoff
+		}
+	}
+}
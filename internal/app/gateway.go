@@ -2,24 +2,40 @@ package app
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
 
+	"github.com/haqury/user-service/internal/auth"
 	pb "github.com/haqury/user-service/pkg/gen"
 )
 
 // StartGatewayServer запускает HTTP Gateway сервер, который проксирует запросы в gRPC
-func StartGatewayServer(ctx context.Context, grpcAddr, httpAddr string) error {
-	// Создаем mux для gRPC-Gateway
-	mux := runtime.NewServeMux()
+func StartGatewayServer(ctx context.Context, app *Application, grpcAddr, httpAddr string) error {
+	// Создаем mux для gRPC-Gateway. Метка gateway добавляется к каждому запросу независимо
+	// от его заголовков: по ней gRPC сервер не аутентифицирует запрос сертификатом gateway
+	mux := runtime.NewServeMux(
+		runtime.WithMetadata(func(context.Context, *http.Request) metadata.MD {
+			return metadata.Pairs(auth.GatewayMetadataKey, "1")
+		}),
+		runtime.WithIncomingHeaderMatcher(gatewayHeaderMatcher),
+	)
 
-	// Опции для подключения к gRPC серверу
+	// Опции для подключения к gRPC серверу: при включенном TLS gateway проверяет
+	// сертификат сервера и предъявляет свой, если сервер требует mTLS
+	creds := insecure.NewCredentials()
+	if app.TLS != nil {
+		creds = credentials.NewTLS(app.TLS.GatewayClientConfig(grpcAddr))
+	}
 	opts := []grpc.DialOption{
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithTransportCredentials(creds),
 	}
 
 	// Регистрируем UserService handler
@@ -36,6 +52,9 @@ func StartGatewayServer(ctx context.Context, grpcAddr, httpAddr string) error {
 		w.Write([]byte(`{"status":"ok","service":"user-service"}`))
 	})
 
+	// Публичные ключи для локальной проверки JWT в api-gateway и video-service
+	healthMux.HandleFunc("/.well-known/jwks.json", jwksHandler(app.Services.Keys))
+
 	// Все остальные запросы идут в gRPC-Gateway
 	healthMux.Handle("/", mux)
 
@@ -45,9 +64,42 @@ func StartGatewayServer(ctx context.Context, grpcAddr, httpAddr string) error {
 		Handler: allowCORS(healthMux),
 	}
 
+	if app.TLS != nil {
+		server.TLSConfig = app.TLS.HTTPServerConfig()
+		return server.ListenAndServeTLS("", "")
+	}
 	return server.ListenAndServe()
 }
 
+// gatewayHeaderMatcher передает заголовки как runtime.DefaultHeaderMatcher, кроме попытки
+// клиента передать метку gateway через Grpc-Metadata-
+func gatewayHeaderMatcher(key string) (string, bool) {
+	if strings.EqualFold(key, runtime.MetadataHeaderPrefix+auth.GatewayMetadataKey) {
+		return "", false
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+// jwksHandler отдает JWK Set связки ключей; в режиме opaque набор пустой
+func jwksHandler(keys *auth.KeyRing) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		set := auth.JWKSet{Keys: []auth.JWK{}}
+		if keys != nil {
+			set = keys.JWKS()
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		// Короткий кэш: новые ключи публикуются заранее, поэтому потребители успеют их подтянуть
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		json.NewEncoder(w).Encode(set)
+	}
+}
+
 // allowCORS добавляет CORS headers
 func allowCORS(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
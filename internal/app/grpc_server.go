@@ -6,9 +6,11 @@ import (
 	"net"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/reflection"
 
 	"github.com/haqury/user-service/internal/handler"
+	"github.com/haqury/user-service/internal/interceptor"
 	pb "github.com/haqury/user-service/pkg/gen"
 )
 
@@ -19,13 +21,27 @@ func StartGRPCServer(app *Application, addr string) error {
 		return fmt.Errorf("failed to listen: %w", err)
 	}
 
-	// Создаем gRPC сервер
-	grpcServer := grpc.NewServer()
+	// Создаем gRPC сервер с аутентификацией и политиками доступа методов
+	authInterceptor := interceptor.NewAuth(app.Services.Auth, interceptor.UserServicePolicies)
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(authInterceptor.Unary()),
+		grpc.ChainStreamInterceptor(authInterceptor.Stream()),
+	}
+	if app.TLS != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(app.TLS.GRPCServerConfig())))
+	}
+	grpcServer := grpc.NewServer(opts...)
 
 	// Создаем handler
 	userServiceServer := handler.NewUserServiceServer(
 		app.Services.User,
+		app.Services.Auth,
 		app.Services.Routing,
+		app.Services.RBAC,
+		app.Services.ServiceAccounts,
+		app.Services.APIKeys,
+		app.Services.Instances,
+		app.TrustedProxies,
 	)
 
 	// Регистрируем сервис
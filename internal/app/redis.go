@@ -0,0 +1,34 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/haqury/user-service/internal/config"
+)
+
+// openRedis подключается к Redis и проверяет доступность
+func openRedis(ctx context.Context, c config.RedisConfig) (*redis.Client, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     fmt.Sprintf("%s:%d", c.Host, c.Port),
+		Password: c.Password,
+		DB:       c.DB,
+	})
+
+	pingCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if err := client.Ping(pingCtx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to ping redis: %w", err)
+	}
+	return client, nil
+}
+
+// needsRedis - хотя бы одна подсистема настроена на хранение в Redis
+func needsRedis(c *config.Config) bool {
+	return c.Auth.Throttle.Enabled && c.Auth.Throttle.Store == config.ThrottleStoreRedis
+}
@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrInvalidJWT - подпись, срок действия или claims токена не прошли проверку
+var ErrInvalidJWT = errors.New("invalid jwt")
+
+// Claims - claims access токена. Sub дублирует UserID для совместимости со стандартными библиотеками
+type Claims struct {
+	UserID           string   `json:"uid"`
+	Roles            []string `json:"roles,omitempty"`
+	SubscriptionTier string   `json:"tier,omitempty"`
+	Region           string   `json:"region,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// KeySource отдает ключ для подписи и ключи для проверки по kid
+type KeySource interface {
+	// SigningKey - текущий активный ключ подписи
+	SigningKey() (*SigningKey, error)
+	// VerificationKey - ключ проверки по kid из заголовка токена
+	VerificationKey(kid string) (*SigningKey, error)
+}
+
+// JWTManager выпускает и проверяет access токены
+type JWTManager struct {
+	keys     KeySource
+	issuer   string
+	audience string
+	ttl      time.Duration
+}
+
+func NewJWTManager(keys KeySource, issuer, audience string, ttl time.Duration) *JWTManager {
+	return &JWTManager{
+		keys:     keys,
+		issuer:   issuer,
+		audience: audience,
+		ttl:      ttl,
+	}
+}
+
+// TTL - время жизни выпускаемых токенов
+func (m *JWTManager) TTL() time.Duration {
+	return m.ttl
+}
+
+// Issue подписывает claims текущим ключом; заполняет iss/aud/iat/nbf/exp и sub
+func (m *JWTManager) Issue(claims *Claims) (string, time.Time, error) {
+	key, err := m.keys.SigningKey()
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to get signing key: %w", err)
+	}
+	method, err := key.Method()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(m.ttl)
+
+	claims.Subject = claims.UserID
+	claims.Issuer = m.issuer
+	claims.IssuedAt = jwt.NewNumericDate(now)
+	claims.NotBefore = jwt.NewNumericDate(now)
+	claims.ExpiresAt = jwt.NewNumericDate(expiresAt)
+	if m.audience != "" {
+		claims.Audience = jwt.ClaimStrings{m.audience}
+	}
+
+	token := jwt.NewWithClaims(method, claims)
+	if key.ID != "" {
+		token.Header["kid"] = key.ID
+	}
+
+	signed, err := token.SignedString(key.Private)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to sign token: %w", err)
+	}
+
+	return signed, expiresAt, nil
+}
+
+// Verify проверяет подпись (ключ выбирается по kid), срок действия, issuer и audience
+func (m *JWTManager) Verify(tokenString string) (*Claims, error) {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{AlgHS256, AlgRS256, AlgEdDSA}),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	}
+	if m.issuer != "" {
+		opts = append(opts, jwt.WithIssuer(m.issuer))
+	}
+	if m.audience != "" {
+		opts = append(opts, jwt.WithAudience(m.audience))
+	}
+
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, err := m.keys.VerificationKey(kid)
+		if err != nil {
+			return nil, err
+		}
+		// Защита от подмены алгоритма (например, RS256 -> HS256 с публичным ключом как секретом)
+		if token.Method.Alg() != key.Algorithm {
+			return nil, fmt.Errorf("%w: token alg %s, key alg %s", ErrUnsupportedAlgorithm, token.Method.Alg(), key.Algorithm)
+		}
+		return key.Public, nil
+	}, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidJWT, err)
+	}
+
+	if claims.UserID == "" {
+		claims.UserID = claims.Subject
+	}
+
+	return claims, nil
+}
+
+// LooksLikeJWT - грубая проверка формата (три сегмента через точку), чтобы отличить JWT от непрозрачного токена
+func LooksLikeJWT(token string) bool {
+	dots := 0
+	for i := 0; i < len(token); i++ {
+		if token[i] == '.' {
+			dots++
+		}
+	}
+	return dots == 2
+}
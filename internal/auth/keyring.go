@@ -0,0 +1,196 @@
+package auth
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+)
+
+// RingKey - ключ в связке с моментом, начиная с которого он подписывает новые токены
+type RingKey struct {
+	*SigningKey
+	ActiveFrom time.Time
+}
+
+// KeyLoader загружает актуальный набор ключей (файлы, конфигурация)
+type KeyLoader func() ([]*RingKey, error)
+
+// KeyRing - связка ключей подписи с плановой ротацией.
+//
+// Ротация задается расписанием: каждый ключ имеет ActiveFrom, подписывает самый поздний
+// ключ с ActiveFrom <= now. Предыдущий ключ выводится из оборота в момент активации
+// следующего и еще overlap принимается при проверке и публикуется в JWKS, чтобы
+// выпущенные им токены дожили до истечения. Будущие ключи публикуются заранее,
+// чтобы потребители JWKS успели их закэшировать. Расписание одинаково для всех реплик,
+// поэтому все инстансы переключаются на новый ключ синхронно
+type KeyRing struct {
+	mu      sync.RWMutex
+	keys    []*RingKey // отсортированы по ActiveFrom
+	overlap time.Duration
+	now     func() time.Time
+}
+
+// NewKeyRing создает связку; overlap должен быть не меньше времени жизни токена
+func NewKeyRing(keys []*RingKey, overlap time.Duration) (*KeyRing, error) {
+	r := &KeyRing{overlap: overlap, now: time.Now}
+	if err := r.Replace(keys); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Replace атомарно заменяет набор ключей (например, после перечитывания файлов)
+func (r *KeyRing) Replace(keys []*RingKey) error {
+	if len(keys) == 0 {
+		return errors.New("key ring must contain at least one key")
+	}
+
+	seen := make(map[string]bool, len(keys))
+	sorted := make([]*RingKey, len(keys))
+	copy(sorted, keys)
+	for _, k := range sorted {
+		if seen[k.ID] {
+			return fmt.Errorf("duplicate key id %q", k.ID)
+		}
+		seen[k.ID] = true
+		if _, err := k.Method(); err != nil {
+			return fmt.Errorf("key %q: %w", k.ID, err)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].ActiveFrom.Before(sorted[j].ActiveFrom)
+	})
+
+	r.mu.Lock()
+	r.keys = sorted
+	r.mu.Unlock()
+	return nil
+}
+
+// SigningKey - последний активированный ключ с приватной частью
+func (r *KeyRing) SigningKey() (*SigningKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := r.now()
+	for i := len(r.keys) - 1; i >= 0; i-- {
+		k := r.keys[i]
+		if !k.ActiveFrom.After(now) && k.CanSign() {
+			return k.SigningKey, nil
+		}
+	}
+	return nil, errors.New("no active signing key")
+}
+
+// VerificationKey возвращает опубликованный ключ (активный, будущий или выведенный
+// из оборота не позднее overlap назад)
+func (r *KeyRing) VerificationKey(kid string) (*SigningKey, error) {
+	for _, k := range r.PublishedKeys() {
+		if k.ID == kid {
+			return k, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownKey, kid)
+}
+
+// PublishedKeys - ключи, токены которых сейчас принимаются
+func (r *KeyRing) PublishedKeys() []*SigningKey {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := r.now()
+	result := make([]*SigningKey, 0, len(r.keys))
+	for i, k := range r.keys {
+		// Ключ выведен из оборота, когда активировался следующий
+		if i+1 < len(r.keys) {
+			retiredAt := r.keys[i+1].ActiveFrom
+			if !retiredAt.After(now) && now.Sub(retiredAt) > r.overlap {
+				continue
+			}
+		}
+		result = append(result, k.SigningKey)
+	}
+	return result
+}
+
+// Run периодически перечитывает ключи через loader до отмены ctx.
+// Ошибки загрузки логируются, текущий набор ключей при этом сохраняется
+func (r *KeyRing) Run(ctx context.Context, interval time.Duration, loader KeyLoader) {
+	if interval <= 0 || loader == nil {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			keys, err := loader()
+			if err == nil {
+				err = r.Replace(keys)
+			}
+			if err != nil {
+				log.Printf("Failed to reload signing keys: %v", err)
+			}
+		}
+	}
+}
+
+// JWK - публичный ключ в формате RFC 7517
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid,omitempty"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Ed25519 (OKP)
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+}
+
+// JWKSet - содержимое /.well-known/jwks.json
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS возвращает публичные части опубликованных ключей. Симметричные HS256 ключи
+// не публикуются: такие токены может проверить только сам user-service
+func (r *KeyRing) JWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	for _, k := range r.PublishedKeys() {
+		switch pub := k.Public.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				KeyType:   "RSA",
+				KeyID:     k.ID,
+				Use:       "sig",
+				Algorithm: k.Algorithm,
+				N:         base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				E:         base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				KeyType:   "OKP",
+				KeyID:     k.ID,
+				Use:       "sig",
+				Algorithm: k.Algorithm,
+				Curve:     "Ed25519",
+				X:         base64.RawURLEncoding.EncodeToString(pub),
+			})
+		}
+	}
+	return set
+}
@@ -0,0 +1,211 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/haqury/user-service/internal/config"
+)
+
+// Поддерживаемые алгоритмы подписи JWT
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+var (
+	// ErrUnknownKey - ключ с указанным kid не найден
+	ErrUnknownKey = errors.New("unknown signing key")
+
+	// ErrUnsupportedAlgorithm - алгоритм подписи не поддерживается
+	ErrUnsupportedAlgorithm = errors.New("unsupported signing algorithm")
+)
+
+// SigningKey - ключ подписи JWT. Для HS256 Private и Public - один и тот же секрет ([]byte),
+// для RS256/EdDSA Public может существовать без Private (ключ только для проверки)
+type SigningKey struct {
+	ID        string
+	Algorithm string
+	Private   crypto.PrivateKey
+	Public    crypto.PublicKey
+}
+
+// Method возвращает метод подписи golang-jwt для алгоритма ключа
+func (k *SigningKey) Method() (jwt.SigningMethod, error) {
+	switch k.Algorithm {
+	case AlgHS256:
+		return jwt.SigningMethodHS256, nil
+	case AlgRS256:
+		return jwt.SigningMethodRS256, nil
+	case AlgEdDSA:
+		return jwt.SigningMethodEdDSA, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, k.Algorithm)
+	}
+}
+
+// CanSign - ключ содержит приватную часть
+func (k *SigningKey) CanSign() bool {
+	return k.Private != nil
+}
+
+// NewHMACKey создает симметричный ключ HS256
+func NewHMACKey(id string, secret []byte) (*SigningKey, error) {
+	if len(secret) < 32 {
+		return nil, errors.New("HS256 secret must be at least 32 bytes")
+	}
+	return &SigningKey{ID: id, Algorithm: AlgHS256, Private: secret, Public: secret}, nil
+}
+
+// LoadKeyFiles загружает асимметричный ключ из PEM файлов. privateFile может быть пустым,
+// тогда ключ годится только для проверки подписи; publicFile может быть пустым,
+// если публичный ключ выводится из приватного
+func LoadKeyFiles(id, algorithm, privateFile, publicFile string) (*SigningKey, error) {
+	key := &SigningKey{ID: id, Algorithm: algorithm}
+
+	if privateFile != "" {
+		data, err := os.ReadFile(privateFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read private key %s: %w", privateFile, err)
+		}
+		if key.Private, key.Public, err = parsePrivateKey(algorithm, data); err != nil {
+			return nil, fmt.Errorf("failed to parse private key %s: %w", privateFile, err)
+		}
+	}
+
+	if publicFile != "" {
+		data, err := os.ReadFile(publicFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read public key %s: %w", publicFile, err)
+		}
+		if key.Public, err = parsePublicKey(algorithm, data); err != nil {
+			return nil, fmt.Errorf("failed to parse public key %s: %w", publicFile, err)
+		}
+	}
+
+	if key.Public == nil {
+		return nil, fmt.Errorf("key %s: neither private nor public key provided", id)
+	}
+
+	return key, nil
+}
+
+func parsePrivateKey(algorithm string, data []byte) (crypto.PrivateKey, crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, nil, errors.New("invalid PEM")
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		if algorithm != AlgRS256 {
+			return nil, nil, fmt.Errorf("RSA key cannot be used with %s", algorithm)
+		}
+		return k, &k.PublicKey, nil
+	case ed25519.PrivateKey:
+		if algorithm != AlgEdDSA {
+			return nil, nil, fmt.Errorf("Ed25519 key cannot be used with %s", algorithm)
+		}
+		return k, k.Public(), nil
+	default:
+		return nil, nil, fmt.Errorf("%w: key type %T", ErrUnsupportedAlgorithm, parsed)
+	}
+}
+
+func parsePublicKey(algorithm string, data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("invalid PEM")
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch k := parsed.(type) {
+	case *rsa.PublicKey:
+		if algorithm != AlgRS256 {
+			return nil, fmt.Errorf("RSA key cannot be used with %s", algorithm)
+		}
+		return k, nil
+	case ed25519.PublicKey:
+		if algorithm != AlgEdDSA {
+			return nil, fmt.Errorf("Ed25519 key cannot be used with %s", algorithm)
+		}
+		return k, nil
+	default:
+		return nil, fmt.Errorf("%w: key type %T", ErrUnsupportedAlgorithm, parsed)
+	}
+}
+
+// KeyFromConfig создает ключ подписи из секции jwt конфигурации
+func KeyFromConfig(cfg config.JWTConfig) (*SigningKey, error) {
+	return keyFromFields(cfg.KeyID, cfg.Algorithm, cfg.Secret, cfg.PrivateKeyFile, cfg.PublicKeyFile)
+}
+
+// RingKeysFromConfig загружает связку ключей из jwt.keys; при пустом списке -
+// единственный ключ из основных полей секции, активный с нулевого момента
+func RingKeysFromConfig(cfg config.JWTConfig) ([]*RingKey, error) {
+	if len(cfg.Keys) == 0 {
+		key, err := KeyFromConfig(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return []*RingKey{{SigningKey: key}}, nil
+	}
+
+	keys := make([]*RingKey, 0, len(cfg.Keys))
+	for _, kc := range cfg.Keys {
+		algorithm := kc.Algorithm
+		if algorithm == "" {
+			algorithm = cfg.Algorithm
+		}
+
+		key, err := keyFromFields(kc.ID, algorithm, kc.Secret, kc.PrivateKeyFile, kc.PublicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", kc.ID, err)
+		}
+		keys = append(keys, &RingKey{SigningKey: key, ActiveFrom: kc.ActiveFrom})
+	}
+	return keys, nil
+}
+
+func keyFromFields(id, algorithm, secret, privateFile, publicFile string) (*SigningKey, error) {
+	switch algorithm {
+	case AlgHS256, "":
+		return NewHMACKey(id, []byte(secret))
+	case AlgRS256, AlgEdDSA:
+		return LoadKeyFiles(id, algorithm, privateFile, publicFile)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, algorithm)
+	}
+}
@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// ErrMissingToken - в запросе нет токена в заголовке authorization
+var ErrMissingToken = errors.New("missing bearer token")
+
+// authorizationHeader - ключ метаданных gRPC. grpc-gateway передает HTTP заголовок
+// Authorization под этим же именем
+const authorizationHeader = "authorization"
+
+// TokenFromContext извлекает токен из метаданных входящего запроса
+// (authorization: Bearer <token>). Схема сравнивается без учета регистра
+func TokenFromContext(ctx context.Context) (string, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", ErrMissingToken
+	}
+
+	values := md.Get(authorizationHeader)
+	if len(values) == 0 {
+		return "", ErrMissingToken
+	}
+
+	scheme, token, found := strings.Cut(strings.TrimSpace(values[0]), " ")
+	if !found || !strings.EqualFold(scheme, "bearer") {
+		return "", ErrMissingToken
+	}
+
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return "", ErrMissingToken
+	}
+	return token, nil
+}
+
+// PeerIdentity возвращает идентичность клиента по проверенному сертификату mTLS:
+// первый URI SAN (например, spiffe://...) или CN. Пустая строка - соединение без
+// проверенного клиентского сертификата
+func PeerIdentity(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.PeerCertificates) == 0 {
+		return ""
+	}
+
+	cert := tlsInfo.State.PeerCertificates[0]
+	if len(cert.URIs) > 0 {
+		return cert.URIs[0].String()
+	}
+	return cert.Subject.CommonName
+}
+
+// GatewayMetadataKey - метка, которую встроенный HTTP gateway добавляет к каждому
+// проксируемому запросу. Ее ставит сам gateway, а не клиент: одноименный заголовок
+// HTTP запроса gateway не передает
+const GatewayMetadataKey = "x-user-service-gateway"
+
+// ViaGateway - запрос пришел через встроенный HTTP gateway. Такие запросы нельзя
+// аутентифицировать по сертификату соединения: это сертификат самого gateway.
+// Прямой gRPC клиент, передавший метку сам, лишь отказывается от аутентификации по mTLS
+func ViaGateway(ctx context.Context) bool {
+	md, ok := metadata.FromIncomingContext(ctx)
+	return ok && len(md.Get(GatewayMetadataKey)) > 0
+}
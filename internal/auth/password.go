@@ -0,0 +1,193 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/haqury/user-service/internal/config"
+)
+
+// Поддерживаемые алгоритмы хэширования паролей
+const (
+	PasswordAlgArgon2id = "argon2id"
+	PasswordAlgBcrypt   = "bcrypt"
+)
+
+const (
+	argon2SaltLen = 16
+	argon2KeyLen  = 32
+)
+
+var (
+	// ErrUnknownPasswordHash - формат сохраненного хэша не распознан
+	ErrUnknownPasswordHash = errors.New("unknown password hash format")
+
+	// ErrPasswordTooLong - bcrypt учитывает только первые 72 байта пароля
+	ErrPasswordTooLong = errors.New("password is too long")
+)
+
+// PasswordHasher хэширует и проверяет пароли. Hash всегда использует текущий алгоритм
+// и параметры, Verify принимает хэши любого поддерживаемого формата
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Verify(password, encoded string) (bool, error)
+	// NeedsRehash - хэш получен другим алгоритмом или с устаревшими параметрами
+	NeedsRehash(encoded string) bool
+}
+
+// Argon2Params - параметры argon2id (Memory в KiB)
+type Argon2Params struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+}
+
+type passwordHasher struct {
+	algorithm  string
+	bcryptCost int
+	argon2     Argon2Params
+}
+
+// NewPasswordHasher создает хэшер по конфигурации
+func NewPasswordHasher(cfg config.PasswordConfig) (PasswordHasher, error) {
+	h := &passwordHasher{
+		algorithm:  cfg.Algorithm,
+		bcryptCost: cfg.BcryptCost,
+		argon2: Argon2Params{
+			Memory:      cfg.Argon2Memory,
+			Iterations:  cfg.Argon2Iterations,
+			Parallelism: cfg.Argon2Parallelism,
+		},
+	}
+
+	switch h.algorithm {
+	case PasswordAlgArgon2id:
+		if h.argon2.Memory == 0 || h.argon2.Iterations == 0 || h.argon2.Parallelism == 0 {
+			return nil, errors.New("argon2id memory, iterations and parallelism must be positive")
+		}
+	case PasswordAlgBcrypt:
+		if h.bcryptCost < bcrypt.MinCost || h.bcryptCost > bcrypt.MaxCost {
+			return nil, fmt.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported password algorithm: %s", h.algorithm)
+	}
+
+	return h, nil
+}
+
+func (h *passwordHasher) Hash(password string) (string, error) {
+	if h.algorithm == PasswordAlgBcrypt {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), h.bcryptCost)
+		if errors.Is(err, bcrypt.ErrPasswordTooLong) {
+			return "", ErrPasswordTooLong
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to hash password: %w", err)
+		}
+		return string(hash), nil
+	}
+
+	salt := make([]byte, argon2SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+	key := argon2.IDKey([]byte(password), salt, h.argon2.Iterations, h.argon2.Memory, h.argon2.Parallelism, argon2KeyLen)
+
+	return encodeArgon2(h.argon2, salt, key), nil
+}
+
+// Verify сравнивает пароль с хэшем за постоянное время. Неверный пароль - (false, nil),
+// ошибка возвращается только для нераспознанного хэша
+func (h *passwordHasher) Verify(password, encoded string) (bool, error) {
+	switch {
+	case strings.HasPrefix(encoded, "$argon2id$"):
+		params, salt, key, err := decodeArgon2(encoded)
+		if err != nil {
+			return false, err
+		}
+		actual := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+		return subtle.ConstantTimeCompare(actual, key) == 1, nil
+
+	case isBcryptHash(encoded):
+		err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+		if err != nil {
+			return false, fmt.Errorf("failed to verify password: %w", err)
+		}
+		return true, nil
+
+	default:
+		return false, ErrUnknownPasswordHash
+	}
+}
+
+func (h *passwordHasher) NeedsRehash(encoded string) bool {
+	switch h.algorithm {
+	case PasswordAlgArgon2id:
+		if !strings.HasPrefix(encoded, "$argon2id$") {
+			return true
+		}
+		params, _, key, err := decodeArgon2(encoded)
+		return err != nil || params != h.argon2 || len(key) != argon2KeyLen
+
+	case PasswordAlgBcrypt:
+		if !isBcryptHash(encoded) {
+			return true
+		}
+		cost, err := bcrypt.Cost([]byte(encoded))
+		return err != nil || cost != h.bcryptCost
+	}
+	return false
+}
+
+func isBcryptHash(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
+}
+
+// encodeArgon2 формирует хэш в формате PHC: $argon2id$v=19$m=65536,t=3,p=2$<salt>$<key>
+func encodeArgon2(p Argon2Params, salt, key []byte) string {
+	return fmt.Sprintf(
+		"$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, p.Memory, p.Iterations, p.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	)
+}
+
+func decodeArgon2(encoded string) (Argon2Params, []byte, []byte, error) {
+	var p Argon2Params
+
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return p, nil, nil, ErrUnknownPasswordHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, ErrUnknownPasswordHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Iterations, &p.Parallelism); err != nil {
+		return p, nil, nil, ErrUnknownPasswordHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, ErrUnknownPasswordHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return p, nil, nil, ErrUnknownPasswordHash
+	}
+
+	return p, salt, key, nil
+}
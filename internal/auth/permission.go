@@ -0,0 +1,27 @@
+package auth
+
+import (
+	"regexp"
+	"strings"
+)
+
+// PermissionAll - право на все действия
+const PermissionAll = "*"
+
+var permissionPattern = regexp.MustCompile(`^(\*|[a-z0-9_.-]+:(\*|[a-z0-9_.-]+)(:[a-z0-9_.-]+)*)$`)
+
+// ValidPermission - право имеет вид "ресурс:действие", "ресурс:*" или "*"
+func ValidPermission(permission string) bool {
+	return len(permission) <= 128 && permissionPattern.MatchString(permission)
+}
+
+// PermissionMatches - выданное право granted покрывает запрошенное requested
+func PermissionMatches(granted, requested string) bool {
+	if granted == PermissionAll || granted == requested {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(granted, "*"); ok {
+		return strings.HasPrefix(requested, prefix)
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"context"
+	"slices"
+)
+
+// RoleAdmin - роль с полным доступом ко всем RPC
+const RoleAdmin = "admin"
+
+// Типы вызывающих
+const (
+	PrincipalUser    = "user"
+	PrincipalService = "service"
+)
+
+// Principal - аутентифицированный вызывающий: пользователь или внутренний сервис.
+// Для сервиса заполнены ServiceAccountID и ServiceName, ролей у него нет
+type Principal struct {
+	Type             string
+	UserID           string
+	Roles            []string
+	SubscriptionTier string
+	Region           string
+
+	ServiceAccountID string
+	ServiceName      string
+}
+
+// IsService - вызывающий является сервисным аккаунтом
+func (p *Principal) IsService() bool {
+	return p.Type == PrincipalService
+}
+
+// HasRole - у вызывающего есть роль
+func (p *Principal) HasRole(role string) bool {
+	return slices.Contains(p.Roles, role)
+}
+
+// IsAdmin - вызывающий администратор
+func (p *Principal) IsAdmin() bool {
+	return p.HasRole(RoleAdmin)
+}
+
+type principalKey struct{}
+
+// WithPrincipal кладет вызывающего в контекст запроса
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext возвращает вызывающего, если запрос аутентифицирован
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok && p != nil
+}
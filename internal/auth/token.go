@@ -0,0 +1,36 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+// opaqueTokenBytes - длина случайной части непрозрачного токена (256 бит)
+const opaqueTokenBytes = 32
+
+// ServiceTokenPrefix - префикс токенов сервисных аккаунтов: по нему токен сервиса
+// отличается от пользовательского без лишнего запроса к БД
+const ServiceTokenPrefix = "svc_"
+
+// APIKeyPrefix - префикс API ключей пользователей: по нему ключ опознается в логах
+// и сканерах секретов и не путается с токеном сессии
+const APIKeyPrefix = "usk_"
+
+// GenerateOpaqueToken генерирует криптографически случайный непрозрачный токен
+func GenerateOpaqueToken() (string, error) {
+	buf := make([]byte, opaqueTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// HashToken возвращает SHA-256 хэш токена в hex. В БД хранится только хэш,
+// поэтому утечка таблицы auth_tokens не дает рабочих токенов
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
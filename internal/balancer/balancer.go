@@ -0,0 +1,115 @@
+// Package balancer содержит стратегии выбора инстанса video-service среди подходящих
+// пользователю (регион, тариф, свободная емкость). Стратегия выбирается по тарифу
+// или региону в конфигурации routing.balancer
+package balancer
+
+import (
+	"fmt"
+	"math/rand/v2"
+
+	"github.com/haqury/user-service/internal/config"
+	"github.com/haqury/user-service/internal/models"
+)
+
+// Strategy выбирает инстанс из непустого списка кандидатов. key - ключ привязки
+// (id пользователя) для стратегий, которым важна стабильность выбора
+type Strategy interface {
+	Name() string
+	Select(key string, candidates []*models.VideoServiceInstance) *models.VideoServiceInstance
+}
+
+// Rand - источник случайных чисел; *rand.Rand из math/rand/v2 подходит, в тестах
+// подставляется источник с фиксированным seed
+type Rand interface {
+	IntN(n int) int
+}
+
+// globalRand - общий потокобезопасный источник math/rand/v2
+type globalRand struct{}
+
+func (globalRand) IntN(n int) int {
+	return rand.IntN(n)
+}
+
+// New создает стратегию по имени из конфигурации. src может быть nil - тогда
+// используется общий источник math/rand/v2
+func New(name string, src Rand, hashReplicas int) (Strategy, error) {
+	if src == nil {
+		src = globalRand{}
+	}
+
+	switch name {
+	case config.BalancerLeastLoad, "":
+		return LeastLoad{}, nil
+	case config.BalancerWeightedRandom:
+		return NewWeightedRandom(src), nil
+	case config.BalancerPowerOfTwo:
+		return NewPowerOfTwo(src), nil
+	case config.BalancerConsistentHash:
+		return NewConsistentHash(hashReplicas), nil
+	default:
+		return nil, fmt.Errorf("unsupported balancer strategy: %s", name)
+	}
+}
+
+// Selector сопоставляет регион и тариф со стратегией. Стратегия тарифа важнее
+// стратегии региона, при отсутствии обеих используется стратегия по умолчанию
+type Selector struct {
+	fallback Strategy
+	regions  map[string]Strategy
+	tiers    map[string]Strategy
+}
+
+// NewSelector создает стратегии из конфигурации; неизвестное имя - ошибка старта
+func NewSelector(c config.BalancerConfig, src Rand) (*Selector, error) {
+	fallback, err := New(c.Default, src, c.HashReplicas)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Selector{
+		fallback: fallback,
+		regions:  make(map[string]Strategy, len(c.Regions)),
+		tiers:    make(map[string]Strategy, len(c.Tiers)),
+	}
+	for region, name := range c.Regions {
+		if s.regions[region], err = New(name, src, c.HashReplicas); err != nil {
+			return nil, fmt.Errorf("region %s: %w", region, err)
+		}
+	}
+	for tier, name := range c.Tiers {
+		if s.tiers[tier], err = New(name, src, c.HashReplicas); err != nil {
+			return nil, fmt.Errorf("tier %s: %w", tier, err)
+		}
+	}
+
+	return s, nil
+}
+
+// For возвращает стратегию для региона и тарифа
+func (s *Selector) For(region, tier string) Strategy {
+	if strategy, ok := s.tiers[tier]; ok {
+		return strategy
+	}
+	if strategy, ok := s.regions[region]; ok {
+		return strategy
+	}
+	return s.fallback
+}
+
+// loadRatio - доля занятой емкости; инстанс без емкости считается заполненным
+func loadRatio(instance *models.VideoServiceInstance) float64 {
+	if instance.MaxCapacity <= 0 {
+		return 1
+	}
+	return float64(instance.CurrentLoad) / float64(instance.MaxCapacity)
+}
+
+// remaining - свободная емкость инстанса
+func remaining(instance *models.VideoServiceInstance) int {
+	free := int(instance.MaxCapacity) - int(instance.CurrentLoad)
+	if free < 0 {
+		return 0
+	}
+	return free
+}
@@ -0,0 +1,112 @@
+package balancer
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/haqury/user-service/internal/config"
+)
+
+// RegionChains строит порядок регионов, в которых ищется инстанс для пользователя:
+// регион пользователя, затем явная цепочка из routing.regions.fallback или, если ее нет,
+// соседи по возрастанию задержки, и в конце config.DefaultRegion
+type RegionChains struct {
+	chains map[string][]string
+}
+
+// NewRegionChains проверяет конфигурацию и заранее строит цепочки для всех регионов,
+// упомянутых в ней; для остальных цепочка - сам регион и default
+func NewRegionChains(c config.RegionConfig) (*RegionChains, error) {
+	latency := make(map[string]map[string]int)
+	for from, to := range c.Latency {
+		for region, value := range to {
+			if from == "" || region == "" {
+				return nil, fmt.Errorf("region latency: empty region name")
+			}
+			if value < 0 {
+				return nil, fmt.Errorf("region latency %s -> %s must not be negative", from, region)
+			}
+			// Задержка симметрична; явно заданное значение в обратную сторону важнее
+			setLatency(latency, from, region, value, true)
+			setLatency(latency, region, from, value, false)
+		}
+	}
+
+	chains := make(map[string][]string)
+	for region, neighbours := range latency {
+		chains[region] = buildChain(region, byLatency(neighbours))
+	}
+	for region, fallback := range c.Fallback {
+		for _, next := range fallback {
+			if next == "" {
+				return nil, fmt.Errorf("region %s: empty region in fallback chain", region)
+			}
+		}
+		chains[region] = buildChain(region, fallback)
+	}
+
+	return &RegionChains{chains: chains}, nil
+}
+
+// Chain возвращает регионы в порядке проверки; индекс региона в цепочке - глубина fallback
+func (r *RegionChains) Chain(region string) []string {
+	if region == "" {
+		region = config.DefaultRegion
+	}
+	if chain, ok := r.chains[region]; ok {
+		return chain
+	}
+	return buildChain(region, nil)
+}
+
+// Depth возвращает глубину fallback региона instanceRegion для пользователя из region
+// или -1, если регион не входит в цепочку
+func (r *RegionChains) Depth(region, instanceRegion string) int {
+	for depth, candidate := range r.Chain(region) {
+		if candidate == instanceRegion {
+			return depth
+		}
+	}
+	return -1
+}
+
+func setLatency(latency map[string]map[string]int, from, to string, value int, explicit bool) {
+	if from == to {
+		return
+	}
+	if latency[from] == nil {
+		latency[from] = make(map[string]int)
+	}
+	if _, ok := latency[from][to]; ok && !explicit {
+		return
+	}
+	latency[from][to] = value
+}
+
+// byLatency упорядочивает соседей по возрастанию задержки, при равенстве - по имени
+func byLatency(neighbours map[string]int) []string {
+	regions := make([]string, 0, len(neighbours))
+	for region := range neighbours {
+		regions = append(regions, region)
+	}
+	sort.Slice(regions, func(i, j int) bool {
+		if neighbours[regions[i]] != neighbours[regions[j]] {
+			return neighbours[regions[i]] < neighbours[regions[j]]
+		}
+		return regions[i] < regions[j]
+	})
+	return regions
+}
+
+// buildChain собирает цепочку без повторов: регион, fallback, default
+func buildChain(region string, fallback []string) []string {
+	chain := make([]string, 0, len(fallback)+2)
+	seen := make(map[string]bool, len(fallback)+2)
+	for _, candidate := range append(append([]string{region}, fallback...), config.DefaultRegion) {
+		if !seen[candidate] {
+			seen[candidate] = true
+			chain = append(chain, candidate)
+		}
+	}
+	return chain
+}
@@ -0,0 +1,156 @@
+package balancer
+
+import (
+	"cmp"
+	"hash/fnv"
+	"slices"
+	"strconv"
+
+	"github.com/haqury/user-service/internal/models"
+)
+
+// LeastLoad выбирает инстанс с наименьшей долей занятой емкости (current_load/max_capacity).
+// При равенстве побеждает инстанс, стоящий раньше в списке
+type LeastLoad struct{}
+
+func (LeastLoad) Name() string {
+	return "least_load"
+}
+
+func (LeastLoad) Select(_ string, candidates []*models.VideoServiceInstance) *models.VideoServiceInstance {
+	best := candidates[0]
+	for _, instance := range candidates[1:] {
+		if loadRatio(instance) < loadRatio(best) {
+			best = instance
+		}
+	}
+	return best
+}
+
+// WeightedRandom выбирает инстанс случайно с вероятностью, пропорциональной свободной емкости
+type WeightedRandom struct {
+	src Rand
+}
+
+func NewWeightedRandom(src Rand) *WeightedRandom {
+	return &WeightedRandom{src: src}
+}
+
+func (*WeightedRandom) Name() string {
+	return "weighted_random"
+}
+
+func (w *WeightedRandom) Select(key string, candidates []*models.VideoServiceInstance) *models.VideoServiceInstance {
+	total := 0
+	for _, instance := range candidates {
+		total += remaining(instance)
+	}
+	if total == 0 {
+		return LeastLoad{}.Select(key, candidates)
+	}
+
+	point := w.src.IntN(total)
+	for _, instance := range candidates {
+		point -= remaining(instance)
+		if point < 0 {
+			return instance
+		}
+	}
+	return candidates[len(candidates)-1]
+}
+
+// PowerOfTwo сравнивает два случайных инстанса и берет менее загруженный: распределение
+// близко к least_load, но параллельные запросы не сходятся на одном инстансе
+type PowerOfTwo struct {
+	src Rand
+}
+
+func NewPowerOfTwo(src Rand) *PowerOfTwo {
+	return &PowerOfTwo{src: src}
+}
+
+func (*PowerOfTwo) Name() string {
+	return "power_of_two"
+}
+
+func (p *PowerOfTwo) Select(_ string, candidates []*models.VideoServiceInstance) *models.VideoServiceInstance {
+	if len(candidates) == 1 {
+		return candidates[0]
+	}
+
+	i := p.src.IntN(len(candidates))
+	j := p.src.IntN(len(candidates) - 1)
+	if j >= i {
+		j++
+	}
+
+	if loadRatio(candidates[j]) < loadRatio(candidates[i]) {
+		return candidates[j]
+	}
+	return candidates[i]
+}
+
+// defaultHashReplicas - число виртуальных узлов инстанса на кольце
+const defaultHashReplicas = 100
+
+// ConsistentHash закрепляет пользователя за инстансом по хэшу его id, чтобы кэши
+// инстанса оставались теплыми. Кольцо с виртуальными узлами строится из кандидатов,
+// поэтому заполненный или выбывший инстанс перераспределяет только своих пользователей
+type ConsistentHash struct {
+	replicas int
+}
+
+func NewConsistentHash(replicas int) *ConsistentHash {
+	if replicas <= 0 {
+		replicas = defaultHashReplicas
+	}
+	return &ConsistentHash{replicas: replicas}
+}
+
+func (*ConsistentHash) Name() string {
+	return "consistent_hash"
+}
+
+type ringNode struct {
+	hash     uint64
+	instance *models.VideoServiceInstance
+}
+
+func (c *ConsistentHash) Select(key string, candidates []*models.VideoServiceInstance) *models.VideoServiceInstance {
+	if len(candidates) == 1 {
+		return candidates[0]
+	}
+
+	ring := make([]ringNode, 0, len(candidates)*c.replicas)
+	for _, instance := range candidates {
+		for i := 0; i < c.replicas; i++ {
+			ring = append(ring, ringNode{hash: hashKey(instance.ID + "#" + strconv.Itoa(i)), instance: instance})
+		}
+	}
+	slices.SortFunc(ring, func(a, b ringNode) int {
+		return cmp.Compare(a.hash, b.hash)
+	})
+
+	idx, _ := slices.BinarySearchFunc(ring, hashKey(key), func(node ringNode, target uint64) int {
+		return cmp.Compare(node.hash, target)
+	})
+	if idx == len(ring) {
+		idx = 0
+	}
+	return ring[idx].instance
+}
+
+// hashKey - FNV-1a с финальным перемешиванием splitmix64: без него близкие строки
+// ("id#1", "id#2") ложатся на кольцо кучно и инстансы получают неравные доли
+func hashKey(key string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+
+	x := h.Sum64()
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
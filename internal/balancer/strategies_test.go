@@ -0,0 +1,271 @@
+package balancer
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/haqury/user-service/internal/models"
+)
+
+// seededRand - источник с фиксированным seed: результаты тестов воспроизводимы
+func seededRand() Rand {
+	return rand.New(rand.NewPCG(1, 2))
+}
+
+// fixedRand всегда возвращает одно и то же смещение от конца диапазона
+type fixedRand struct {
+	fromEnd int
+}
+
+func (r fixedRand) IntN(n int) int {
+	return n - 1 - r.fromEnd
+}
+
+func instance(id string, load, capacity int32) *models.VideoServiceInstance {
+	return &models.VideoServiceInstance{ID: id, CurrentLoad: load, MaxCapacity: capacity}
+}
+
+func TestLeastLoad(t *testing.T) {
+	tests := []struct {
+		name       string
+		candidates []*models.VideoServiceInstance
+		want       string
+	}{
+		{
+			name:       "single candidate",
+			candidates: []*models.VideoServiceInstance{instance("a", 9, 10)},
+			want:       "a",
+		},
+		{
+			name: "ratio wins over absolute load",
+			candidates: []*models.VideoServiceInstance{
+				instance("small", 8, 10),
+				instance("large", 50, 100),
+			},
+			want: "large",
+		},
+		{
+			name: "tie keeps earlier candidate",
+			candidates: []*models.VideoServiceInstance{
+				instance("first", 5, 10),
+				instance("second", 10, 20),
+				instance("third", 1, 2),
+			},
+			want: "first",
+		},
+		{
+			name: "zero capacity counts as full",
+			candidates: []*models.VideoServiceInstance{
+				instance("empty", 0, 0),
+				instance("busy", 9, 10),
+			},
+			want: "busy",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := LeastLoad{}.Select("user", tt.candidates)
+			if got.ID != tt.want {
+				t.Errorf("Select() = %s, want %s", got.ID, tt.want)
+			}
+		})
+	}
+}
+
+func TestWeightedRandom(t *testing.T) {
+	// Свободная емкость: a - 4, b - 0, c - 6; точки 0..3 попадают в a, 4..9 - в c
+	candidates := []*models.VideoServiceInstance{
+		instance("a", 6, 10),
+		instance("b", 10, 10),
+		instance("c", 4, 10),
+	}
+
+	tests := []struct {
+		name       string
+		src        Rand
+		candidates []*models.VideoServiceInstance
+		want       string
+	}{
+		{name: "last point of range", src: fixedRand{}, candidates: candidates, want: "c"},
+		{name: "first point of c", src: fixedRand{fromEnd: 5}, candidates: candidates, want: "c"},
+		{name: "last point of a", src: fixedRand{fromEnd: 6}, candidates: candidates, want: "a"},
+		{name: "first point of range", src: fixedRand{fromEnd: 9}, candidates: candidates, want: "a"},
+		{
+			name: "all full falls back to least load",
+			src:  fixedRand{},
+			candidates: []*models.VideoServiceInstance{
+				instance("x", 12, 10),
+				instance("y", 10, 10),
+			},
+			want: "y",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewWeightedRandom(tt.src).Select("user", tt.candidates)
+			if got.ID != tt.want {
+				t.Errorf("Select() = %s, want %s", got.ID, tt.want)
+			}
+		})
+	}
+}
+
+func TestWeightedRandomDistribution(t *testing.T) {
+	candidates := []*models.VideoServiceInstance{
+		instance("a", 90, 100), // свободно 10
+		instance("b", 70, 100), // свободно 30
+		instance("c", 40, 100), // свободно 60
+	}
+	want := map[string]float64{"a": 0.1, "b": 0.3, "c": 0.6}
+
+	const draws = 10000
+	strategy := NewWeightedRandom(seededRand())
+	counts := make(map[string]int)
+	for i := 0; i < draws; i++ {
+		counts[strategy.Select("user", candidates).ID]++
+	}
+
+	for id, share := range want {
+		got := float64(counts[id]) / draws
+		if got < share-0.03 || got > share+0.03 {
+			t.Errorf("share of %s = %.3f, want %.2f±0.03", id, got, share)
+		}
+	}
+}
+
+func TestPowerOfTwo(t *testing.T) {
+	tests := []struct {
+		name       string
+		candidates []*models.VideoServiceInstance
+		// allowed - инстансы, которые может выбрать стратегия
+		allowed map[string]bool
+	}{
+		{
+			name:       "single candidate",
+			candidates: []*models.VideoServiceInstance{instance("a", 9, 10)},
+			allowed:    map[string]bool{"a": true},
+		},
+		{
+			name: "two candidates always picks less loaded",
+			candidates: []*models.VideoServiceInstance{
+				instance("busy", 8, 10),
+				instance("idle", 1, 10),
+			},
+			allowed: map[string]bool{"idle": true},
+		},
+		{
+			name: "most loaded never picked",
+			candidates: []*models.VideoServiceInstance{
+				instance("a", 1, 10),
+				instance("b", 9, 10),
+				instance("c", 3, 10),
+				instance("d", 5, 10),
+			},
+			allowed: map[string]bool{"a": true, "c": true, "d": true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			strategy := NewPowerOfTwo(seededRand())
+			seen := make(map[string]bool)
+			for i := 0; i < 1000; i++ {
+				got := strategy.Select("user", tt.candidates)
+				if !tt.allowed[got.ID] {
+					t.Fatalf("Select() = %s, want one of %v", got.ID, tt.allowed)
+				}
+				seen[got.ID] = true
+			}
+			if len(seen) != len(tt.allowed) {
+				t.Errorf("picked %v, want every instance of %v", seen, tt.allowed)
+			}
+		})
+	}
+}
+
+func TestConsistentHashStable(t *testing.T) {
+	candidates := []*models.VideoServiceInstance{
+		instance("a", 0, 10),
+		instance("b", 5, 10),
+		instance("c", 9, 10),
+	}
+	reversed := []*models.VideoServiceInstance{candidates[2], candidates[1], candidates[0]}
+
+	tests := []struct {
+		name     string
+		replicas int
+	}{
+		{name: "default replicas", replicas: 0},
+		{name: "single replica", replicas: 1},
+		{name: "many replicas", replicas: 500},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			strategy := NewConsistentHash(tt.replicas)
+			for i := 0; i < 200; i++ {
+				key := fmt.Sprintf("user-%d", i)
+				first := strategy.Select(key, candidates)
+				if again := strategy.Select(key, candidates); again != first {
+					t.Fatalf("key %s: Select() = %s, then %s", key, first.ID, again.ID)
+				}
+				if other := strategy.Select(key, reversed); other != first {
+					t.Fatalf("key %s: Select() depends on candidate order: %s vs %s", key, first.ID, other.ID)
+				}
+			}
+		})
+	}
+}
+
+func TestConsistentHashRemoval(t *testing.T) {
+	candidates := []*models.VideoServiceInstance{
+		instance("a", 0, 10),
+		instance("b", 0, 10),
+		instance("c", 0, 10),
+		instance("d", 0, 10),
+	}
+
+	tests := []struct {
+		name    string
+		removed int
+	}{
+		{name: "remove first", removed: 0},
+		{name: "remove middle", removed: 2},
+		{name: "remove last", removed: 3},
+	}
+
+	strategy := NewConsistentHash(0)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			removedID := candidates[tt.removed].ID
+			rest := make([]*models.VideoServiceInstance, 0, len(candidates)-1)
+			rest = append(rest, candidates[:tt.removed]...)
+			rest = append(rest, candidates[tt.removed+1:]...)
+
+			moved := 0
+			const keys = 2000
+			for i := 0; i < keys; i++ {
+				key := fmt.Sprintf("user-%d", i)
+				before := strategy.Select(key, candidates)
+				after := strategy.Select(key, rest)
+
+				if before.ID != removedID {
+					if after != before {
+						t.Fatalf("key %s moved from %s to %s, but only %s was removed",
+							key, before.ID, after.ID, removedID)
+					}
+					continue
+				}
+				moved++
+			}
+
+			// Каждый инстанс владеет примерно четвертью ключей
+			if moved < keys/8 || moved > keys*3/8 {
+				t.Errorf("%d of %d keys belonged to %s, want about %d", moved, keys, removedID, keys/4)
+			}
+		})
+	}
+}
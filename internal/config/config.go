@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -14,7 +15,15 @@ type PreConfig struct {
 	Server   ServerConfig   `yaml:"server"`
 	Database DatabaseConfig `yaml:"database"`
 	Redis    RedisConfig    `yaml:"redis"`
+	TLS      TLSConfig      `yaml:"tls"`
+	Auth     AuthConfig     `yaml:"auth"`
+	Password PasswordConfig `yaml:"password"`
+	RBAC     RBACConfig     `yaml:"rbac"`
+	Routing  RoutingConfig  `yaml:"routing"`
+	JWT      JWTConfig      `yaml:"jwt"`
 	Log      LogConfig      `yaml:"log"`
+
+	StreamTicket StreamTicketConfig `yaml:"stream_ticket"`
 }
 
 type ServerConfig struct {
@@ -32,6 +41,46 @@ type DatabaseConfig struct {
 	Password string `yaml:"password"`
 	Name     string `yaml:"name"`
 	SSLMode  string `yaml:"ssl_mode"`
+
+	// Параметры пула соединений
+	MaxConnections     int           `yaml:"max_connections"`
+	MaxIdleConnections int           `yaml:"max_idle_connections"`
+	ConnMaxLifetime    time.Duration `yaml:"conn_max_lifetime"`
+	ConnMaxIdleTime    time.Duration `yaml:"conn_max_idle_time"`
+
+	// Повторные попытки подключения при старте (backoff удваивается до MaxConnectBackoff)
+	ConnectRetries    int           `yaml:"connect_retries"`
+	ConnectBackoff    time.Duration `yaml:"connect_backoff"`
+	MaxConnectBackoff time.Duration `yaml:"max_connect_backoff"`
+}
+
+// Режимы проверки клиентских сертификатов gRPC сервером
+const (
+	TLSClientAuthNone     = "none"
+	TLSClientAuthOptional = "optional" // проверяется, если предъявлен (сервисы по mTLS, пользователи по токену)
+	TLSClientAuthRequire  = "require"
+)
+
+// TLSConfig - TLS для gRPC сервера и HTTP gateway. Файлы перечитываются при изменении
+type TLSConfig struct {
+	Enabled  bool   `yaml:"enabled"`
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+
+	// ClientAuth - none, optional или require; для optional и require нужен ClientCAFile
+	ClientAuth   string `yaml:"client_auth"`
+	ClientCAFile string `yaml:"client_ca_file"`
+
+	// Соединение gateway с gRPC сервером: CA для проверки сервера (пусто - системные),
+	// имя в сертификате сервера (пусто - хост адреса) и клиентский сертификат для mTLS
+	// (пусто - сертификат сервера)
+	ServerCAFile    string `yaml:"server_ca_file"`
+	ServerName      string `yaml:"server_name"`
+	GatewayCertFile string `yaml:"gateway_cert_file"`
+	GatewayKeyFile  string `yaml:"gateway_key_file"`
+
+	// ReloadInterval - период проверки изменения файлов (0 - только при старте)
+	ReloadInterval time.Duration `yaml:"reload_interval"`
 }
 
 type RedisConfig struct {
@@ -44,6 +93,221 @@ type RedisConfig struct {
 type JWTConfig struct {
 	Secret     string        `yaml:"secret"`
 	Expiration time.Duration `yaml:"expiration"`
+
+	// Algorithm - HS256 (используется Secret), RS256 или EdDSA (используются PEM файлы)
+	Algorithm      string `yaml:"algorithm"`
+	KeyID          string `yaml:"key_id"`
+	PrivateKeyFile string `yaml:"private_key_file"`
+	PublicKeyFile  string `yaml:"public_key_file"`
+
+	Issuer   string `yaml:"issuer"`
+	Audience string `yaml:"audience"`
+
+	// Keys - связка ключей для плановой ротации. Если не задана, используется
+	// единственный ключ из полей выше
+	Keys []JWTKeyConfig `yaml:"keys"`
+	// RotationOverlap - сколько выведенный из оборота ключ еще принимается и публикуется в JWKS
+	// (должно быть не меньше Expiration)
+	RotationOverlap time.Duration `yaml:"rotation_overlap"`
+	// KeysReloadInterval - период перечитывания файлов ключей (0 - только при старте)
+	KeysReloadInterval time.Duration `yaml:"keys_reload_interval"`
+}
+
+// JWTKeyConfig - ключ в связке. Ключ без private_key_file используется только для проверки
+type JWTKeyConfig struct {
+	ID             string    `yaml:"id"`
+	Algorithm      string    `yaml:"algorithm"`
+	Secret         string    `yaml:"secret"`
+	PrivateKeyFile string    `yaml:"private_key_file"`
+	PublicKeyFile  string    `yaml:"public_key_file"`
+	ActiveFrom     time.Time `yaml:"active_from"`
+}
+
+// Стратегии выбора инстанса video-service (см. internal/balancer)
+const (
+	BalancerLeastLoad      = "least_load"      // наименьшая доля занятой емкости
+	BalancerWeightedRandom = "weighted_random" // случайно, пропорционально свободной емкости
+	BalancerPowerOfTwo     = "power_of_two"    // менее загруженный из двух случайных
+	BalancerConsistentHash = "consistent_hash" // по хэшу id пользователя
+)
+
+// RoutingConfig - выбор инстанса video-service для клиента
+type RoutingConfig struct {
+	Balancer    BalancerConfig    `yaml:"balancer"`
+	HealthCheck HealthCheckConfig `yaml:"health_check"`
+	Sessions    SessionConfig     `yaml:"sessions"`
+	Regions     RegionConfig      `yaml:"regions"`
+
+	// Entitlements - лимиты потока по тарифу; тариф без записи не ограничен
+	Entitlements map[string]EntitlementConfig `yaml:"entitlements"`
+}
+
+// EntitlementConfig - лимиты потока тарифа; 0 - без ограничения
+type EntitlementConfig struct {
+	MaxBitrate    int32 `yaml:"max_bitrate"`    // кбит/с
+	MaxResolution int32 `yaml:"max_resolution"` // высота кадра, например 1080
+}
+
+// DefaultRegion - регион инстансов, которые обслуживают пользователей без подходящих
+// инстансов в своей цепочке регионов; всегда проверяется последним
+const DefaultRegion = "default"
+
+// RegionConfig - порядок регионов, в которых ищется инстанс для пользователя. Сначала
+// проверяется регион пользователя, затем его цепочка и в конце default
+type RegionConfig struct {
+	// Fallback - явные цепочки: регион пользователя -> регионы по убыванию предпочтения
+	Fallback map[string][]string `yaml:"fallback"`
+	// Latency - задержка (или расстояние) между регионами в условных единицах, симметричная.
+	// Для регионов без явной цепочки она строится из соседей по возрастанию задержки
+	Latency map[string]map[string]int `yaml:"latency"`
+}
+
+// SessionConfig - освобождение инстансов, назначенных клиентам, которые перестали
+// присылать heartbeat сессии стриминга
+type SessionConfig struct {
+	// ClientTTL - клиент без heartbeat дольше этого считается отключившимся
+	ClientTTL time.Duration `yaml:"client_ttl"`
+	// ReapInterval - период поиска отключившихся клиентов; 0 - не освобождать автоматически
+	ReapInterval time.Duration `yaml:"reap_interval"`
+	// ReapBatch - сколько назначений снимается одним запросом
+	ReapBatch int `yaml:"reap_batch"`
+	// DrainTimeout - сколько назначенные клиенты остаются на выводимом (draining) инстансе,
+	// если срок не указан при смене состояния
+	DrainTimeout time.Duration `yaml:"drain_timeout"`
+}
+
+// Протоколы проверки здоровья инстансов
+const (
+	HealthCheckGRPC = "grpc" // grpc.health.v1.Health/Check
+	HealthCheckHTTP = "http" // GET, успех - код 2xx
+)
+
+// HealthCheckConfig - фоновая проверка здоровья инстансов video-service. Протокол, адрес
+// и путь инстанса можно переопределить в metadata.health_check
+// ({"protocol": "http", "address": "host:port", "path": "/healthz", "service": ""})
+type HealthCheckConfig struct {
+	Enabled     bool          `yaml:"enabled"`
+	Interval    time.Duration `yaml:"interval"`
+	Timeout     time.Duration `yaml:"timeout"`
+	Concurrency int           `yaml:"concurrency"`
+
+	// Protocol и HTTPPath - значения по умолчанию для инстансов без metadata.health_check
+	Protocol string `yaml:"protocol"`
+	HTTPPath string `yaml:"http_path"`
+
+	// DegradedLatency - успешный ответ медленнее этого считается признаком деградации
+	DegradedLatency time.Duration `yaml:"degraded_latency"`
+
+	// Гистерезис: сколько проверок подряд нужно для смены состояния
+	HealthyAfter   int `yaml:"healthy_after"`   // успешных - для возврата в healthy
+	DegradedAfter  int `yaml:"degraded_after"`  // медленных или неуспешных - для перехода healthy -> degraded
+	UnhealthyAfter int `yaml:"unhealthy_after"` // неуспешных - для перехода в unhealthy
+}
+
+// BalancerConfig - стратегия по умолчанию и переопределения для тарифов и регионов.
+// Стратегия тарифа важнее стратегии региона
+type BalancerConfig struct {
+	Default string            `yaml:"default"`
+	Regions map[string]string `yaml:"regions"`
+	Tiers   map[string]string `yaml:"tiers"`
+	// HashReplicas - число виртуальных узлов инстанса для consistent_hash
+	HashReplicas int `yaml:"hash_replicas"`
+}
+
+// StreamTicketConfig - подпись билетов на подключение потока к video-service
+// (см. pkg/streamticket). Без секрета клиент получает API ключ вместо билета
+type StreamTicketConfig struct {
+	// Secret - общий с video-service секрет HMAC, не короче 32 байт
+	Secret string `yaml:"secret"`
+	// KeyID - идентификатор секрета (kid) для его замены без отказов
+	KeyID string `yaml:"key_id"`
+	// TTL - срок действия билета: клиент должен подключиться за это время
+	TTL time.Duration `yaml:"ttl"`
+}
+
+// Режимы выдачи access токенов
+const (
+	AuthModeOpaque = "opaque"
+	AuthModeJWT    = "jwt"
+)
+
+// AuthConfig - параметры выдачи токенов доступа
+type AuthConfig struct {
+	// Mode - opaque (случайный токен в auth_tokens) или jwt (подписанный токен, см. JWTConfig)
+	Mode string `yaml:"mode"`
+
+	// TokenTTL - время жизни непрозрачного токена доступа (auth_tokens)
+	TokenTTL time.Duration `yaml:"token_ttl"`
+
+	// RefreshTokenTTL - время жизни refresh токена (ротируется при каждом обмене)
+	RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl"`
+
+	// ServiceTokenTTL - время жизни токена сервисного аккаунта (client credentials)
+	ServiceTokenTTL time.Duration `yaml:"service_token_ttl"`
+
+	// Throttle - защита от перебора паролей
+	Throttle LoginThrottleConfig `yaml:"throttle"`
+
+	// APIKeys - API ключи клиентов стриминга
+	APIKeys APIKeyConfig `yaml:"api_keys"`
+}
+
+// APIKeyConfig - сроки действия API ключей
+type APIKeyConfig struct {
+	// DefaultTTL - срок ключа, если при создании он не указан, и ключей, выдаваемых
+	// вместе с конфигурацией стриминга
+	DefaultTTL time.Duration `yaml:"default_ttl"`
+	// MaxTTL - максимальный срок, который можно запросить при создании ключа
+	MaxTTL time.Duration `yaml:"max_ttl"`
+}
+
+// Хранилища счетчиков попыток входа
+const (
+	ThrottleStoreMemory = "memory"
+	ThrottleStoreRedis  = "redis"
+)
+
+// LoginThrottleConfig - ограничение неудачных попыток входа по username и по IP
+type LoginThrottleConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Store - memory (счетчики в процессе) или redis (общие для всех реплик)
+	Store string `yaml:"store"`
+
+	// Window - счетчик ошибок сбрасывается через Window после последней ошибки
+	Window time.Duration `yaml:"window"`
+
+	// FreeAttempts - число ошибок без задержки; далее задержка BaseDelay удваивается до MaxDelay
+	FreeAttempts int           `yaml:"free_attempts"`
+	BaseDelay    time.Duration `yaml:"base_delay"`
+	MaxDelay     time.Duration `yaml:"max_delay"`
+
+	// После MaxUserFailures (MaxIPFailures) ошибок вход блокируется на LockoutDuration
+	MaxUserFailures int           `yaml:"max_user_failures"`
+	MaxIPFailures   int           `yaml:"max_ip_failures"`
+	LockoutDuration time.Duration `yaml:"lockout_duration"`
+
+	// TrustedProxies - CIDR (или адреса) api-gateway и балансировщиков: для соединений от них
+	// IP клиента берется из x-forwarded-for. Loopback (встроенный HTTP gateway) доверен всегда
+	TrustedProxies []string `yaml:"trusted_proxies"`
+}
+
+// PasswordConfig - параметры хэширования паролей. Хэши, созданные другим алгоритмом
+// или с другими параметрами, пересчитываются при успешном входе
+type PasswordConfig struct {
+	// Algorithm - argon2id или bcrypt
+	Algorithm  string `yaml:"algorithm"`
+	BcryptCost int    `yaml:"bcrypt_cost"`
+
+	// Argon2Memory - объем памяти в KiB
+	Argon2Memory      uint32 `yaml:"argon2_memory"`
+	Argon2Iterations  uint32 `yaml:"argon2_iterations"`
+	Argon2Parallelism uint8  `yaml:"argon2_parallelism"`
+}
+
+// RBACConfig - параметры проверки прав
+type RBACConfig struct {
+	// CacheTTL - время жизни кэша прав ролей (0 - без кэша)
+	CacheTTL time.Duration `yaml:"cache_ttl"`
 }
 
 type LogConfig struct {
@@ -61,9 +325,30 @@ type Config struct {
 	HTTPPort string
 	GRPCPort string
 	Env      string
-	Database struct {
-		DSN string
-	}
+	Database DatabaseConnConfig
+	Redis    RedisConfig
+	TLS      TLSConfig
+	Auth     AuthConfig
+	Password PasswordConfig
+	RBAC     RBACConfig
+	Routing  RoutingConfig
+	JWT      JWTConfig
+
+	StreamTicket StreamTicketConfig
+}
+
+// DatabaseConnConfig - параметры подключения к БД и пула соединений
+type DatabaseConnConfig struct {
+	DSN string
+
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+
+	ConnectRetries    int
+	ConnectBackoff    time.Duration
+	MaxConnectBackoff time.Duration
 }
 
 // NewConfig создает конфигурацию приложения
@@ -95,11 +380,25 @@ func NewConfig(cfgPath string, grpcPort string) (*Config, error) {
 		HTTPPort: fmt.Sprintf("%d", appConfig.Server.Port),
 		GRPCPort: grpcPort,
 		Env:      appConfig.Server.Mode,
-		Database: struct {
-			DSN string
-		}{
-			DSN: dsn,
+		Database: DatabaseConnConfig{
+			DSN:               dsn,
+			MaxOpenConns:      appConfig.Database.MaxConnections,
+			MaxIdleConns:      appConfig.Database.MaxIdleConnections,
+			ConnMaxLifetime:   appConfig.Database.ConnMaxLifetime,
+			ConnMaxIdleTime:   appConfig.Database.ConnMaxIdleTime,
+			ConnectRetries:    appConfig.Database.ConnectRetries,
+			ConnectBackoff:    appConfig.Database.ConnectBackoff,
+			MaxConnectBackoff: appConfig.Database.MaxConnectBackoff,
 		},
+		Redis:    appConfig.Redis,
+		TLS:      appConfig.TLS,
+		Auth:     appConfig.Auth,
+		Password: appConfig.Password,
+		RBAC:     appConfig.RBAC,
+		Routing:  appConfig.Routing,
+		JWT:      appConfig.JWT,
+
+		StreamTicket: appConfig.StreamTicket,
 	}, nil
 }
 
@@ -126,6 +425,36 @@ func overrideFromEnv(cfg *PreConfig) *PreConfig {
 	if sslMode := os.Getenv("DB_SSLMODE"); sslMode != "" {
 		cfg.Database.SSLMode = sslMode
 	}
+	if maxConns := os.Getenv("DB_MAX_CONNECTIONS"); maxConns != "" {
+		if n, err := strconv.Atoi(maxConns); err == nil {
+			cfg.Database.MaxConnections = n
+		}
+	}
+	if maxIdle := os.Getenv("DB_MAX_IDLE_CONNECTIONS"); maxIdle != "" {
+		if n, err := strconv.Atoi(maxIdle); err == nil {
+			cfg.Database.MaxIdleConnections = n
+		}
+	}
+	if lifetime := os.Getenv("DB_CONN_MAX_LIFETIME"); lifetime != "" {
+		if d, err := time.ParseDuration(lifetime); err == nil {
+			cfg.Database.ConnMaxLifetime = d
+		}
+	}
+	if idleTime := os.Getenv("DB_CONN_MAX_IDLE_TIME"); idleTime != "" {
+		if d, err := time.ParseDuration(idleTime); err == nil {
+			cfg.Database.ConnMaxIdleTime = d
+		}
+	}
+	if retries := os.Getenv("DB_CONNECT_RETRIES"); retries != "" {
+		if n, err := strconv.Atoi(retries); err == nil {
+			cfg.Database.ConnectRetries = n
+		}
+	}
+	if backoff := os.Getenv("DB_CONNECT_BACKOFF"); backoff != "" {
+		if d, err := time.ParseDuration(backoff); err == nil {
+			cfg.Database.ConnectBackoff = d
+		}
+	}
 
 	// Redis
 	if host := os.Getenv("REDIS_HOST"); host != "" {
@@ -145,6 +474,194 @@ func overrideFromEnv(cfg *PreConfig) *PreConfig {
 		}
 	}
 
+	// TLS
+	if enabled := os.Getenv("TLS_ENABLED"); enabled != "" {
+		if b, err := strconv.ParseBool(enabled); err == nil {
+			cfg.TLS.Enabled = b
+		}
+	}
+	if certFile := os.Getenv("TLS_CERT_FILE"); certFile != "" {
+		cfg.TLS.CertFile = certFile
+	}
+	if keyFile := os.Getenv("TLS_KEY_FILE"); keyFile != "" {
+		cfg.TLS.KeyFile = keyFile
+	}
+	if clientAuth := os.Getenv("TLS_CLIENT_AUTH"); clientAuth != "" {
+		cfg.TLS.ClientAuth = clientAuth
+	}
+	if clientCA := os.Getenv("TLS_CLIENT_CA_FILE"); clientCA != "" {
+		cfg.TLS.ClientCAFile = clientCA
+	}
+	if serverCA := os.Getenv("TLS_SERVER_CA_FILE"); serverCA != "" {
+		cfg.TLS.ServerCAFile = serverCA
+	}
+
+	// Auth
+	if mode := os.Getenv("AUTH_MODE"); mode != "" {
+		cfg.Auth.Mode = mode
+	}
+	if ttl := os.Getenv("AUTH_TOKEN_TTL"); ttl != "" {
+		if d, err := time.ParseDuration(ttl); err == nil {
+			cfg.Auth.TokenTTL = d
+		}
+	}
+	if ttl := os.Getenv("AUTH_REFRESH_TOKEN_TTL"); ttl != "" {
+		if d, err := time.ParseDuration(ttl); err == nil {
+			cfg.Auth.RefreshTokenTTL = d
+		}
+	}
+
+	if ttl := os.Getenv("AUTH_SERVICE_TOKEN_TTL"); ttl != "" {
+		if d, err := time.ParseDuration(ttl); err == nil {
+			cfg.Auth.ServiceTokenTTL = d
+		}
+	}
+	if ttl := os.Getenv("API_KEY_DEFAULT_TTL"); ttl != "" {
+		if d, err := time.ParseDuration(ttl); err == nil {
+			cfg.Auth.APIKeys.DefaultTTL = d
+		}
+	}
+	if ttl := os.Getenv("API_KEY_MAX_TTL"); ttl != "" {
+		if d, err := time.ParseDuration(ttl); err == nil {
+			cfg.Auth.APIKeys.MaxTTL = d
+		}
+	}
+	if enabled := os.Getenv("LOGIN_THROTTLE_ENABLED"); enabled != "" {
+		if b, err := strconv.ParseBool(enabled); err == nil {
+			cfg.Auth.Throttle.Enabled = b
+		}
+	}
+	if store := os.Getenv("LOGIN_THROTTLE_STORE"); store != "" {
+		cfg.Auth.Throttle.Store = store
+	}
+	if maxUser := os.Getenv("LOGIN_THROTTLE_MAX_USER_FAILURES"); maxUser != "" {
+		if n, err := strconv.Atoi(maxUser); err == nil {
+			cfg.Auth.Throttle.MaxUserFailures = n
+		}
+	}
+	if maxIP := os.Getenv("LOGIN_THROTTLE_MAX_IP_FAILURES"); maxIP != "" {
+		if n, err := strconv.Atoi(maxIP); err == nil {
+			cfg.Auth.Throttle.MaxIPFailures = n
+		}
+	}
+	if lockout := os.Getenv("LOGIN_THROTTLE_LOCKOUT_DURATION"); lockout != "" {
+		if d, err := time.ParseDuration(lockout); err == nil {
+			cfg.Auth.Throttle.LockoutDuration = d
+		}
+	}
+	if proxies := os.Getenv("LOGIN_THROTTLE_TRUSTED_PROXIES"); proxies != "" {
+		cfg.Auth.Throttle.TrustedProxies = strings.Split(proxies, ",")
+	}
+
+	// Password
+	if algorithm := os.Getenv("PASSWORD_ALGORITHM"); algorithm != "" {
+		cfg.Password.Algorithm = algorithm
+	}
+	if cost := os.Getenv("PASSWORD_BCRYPT_COST"); cost != "" {
+		if n, err := strconv.Atoi(cost); err == nil {
+			cfg.Password.BcryptCost = n
+		}
+	}
+	if memory := os.Getenv("PASSWORD_ARGON2_MEMORY"); memory != "" {
+		if n, err := strconv.ParseUint(memory, 10, 32); err == nil {
+			cfg.Password.Argon2Memory = uint32(n)
+		}
+	}
+	if iterations := os.Getenv("PASSWORD_ARGON2_ITERATIONS"); iterations != "" {
+		if n, err := strconv.ParseUint(iterations, 10, 32); err == nil {
+			cfg.Password.Argon2Iterations = uint32(n)
+		}
+	}
+	if parallelism := os.Getenv("PASSWORD_ARGON2_PARALLELISM"); parallelism != "" {
+		if n, err := strconv.ParseUint(parallelism, 10, 8); err == nil {
+			cfg.Password.Argon2Parallelism = uint8(n)
+		}
+	}
+
+	// RBAC
+	if ttl := os.Getenv("RBAC_CACHE_TTL"); ttl != "" {
+		if d, err := time.ParseDuration(ttl); err == nil {
+			cfg.RBAC.CacheTTL = d
+		}
+	}
+
+	// JWT
+	if secret := os.Getenv("JWT_SECRET"); secret != "" {
+		cfg.JWT.Secret = secret
+	}
+	if expiration := os.Getenv("JWT_EXPIRATION"); expiration != "" {
+		if d, err := time.ParseDuration(expiration); err == nil {
+			cfg.JWT.Expiration = d
+		}
+	}
+	if algorithm := os.Getenv("JWT_ALGORITHM"); algorithm != "" {
+		cfg.JWT.Algorithm = algorithm
+	}
+	if keyID := os.Getenv("JWT_KEY_ID"); keyID != "" {
+		cfg.JWT.KeyID = keyID
+	}
+	if privateKey := os.Getenv("JWT_PRIVATE_KEY_FILE"); privateKey != "" {
+		cfg.JWT.PrivateKeyFile = privateKey
+	}
+	if publicKey := os.Getenv("JWT_PUBLIC_KEY_FILE"); publicKey != "" {
+		cfg.JWT.PublicKeyFile = publicKey
+	}
+	if issuer := os.Getenv("JWT_ISSUER"); issuer != "" {
+		cfg.JWT.Issuer = issuer
+	}
+	if audience := os.Getenv("JWT_AUDIENCE"); audience != "" {
+		cfg.JWT.Audience = audience
+	}
+	if overlap := os.Getenv("JWT_ROTATION_OVERLAP"); overlap != "" {
+		if d, err := time.ParseDuration(overlap); err == nil {
+			cfg.JWT.RotationOverlap = d
+		}
+	}
+	if reload := os.Getenv("JWT_KEYS_RELOAD_INTERVAL"); reload != "" {
+		if d, err := time.ParseDuration(reload); err == nil {
+			cfg.JWT.KeysReloadInterval = d
+		}
+	}
+
+	// Routing
+	if strategy := os.Getenv("ROUTING_BALANCER"); strategy != "" {
+		cfg.Routing.Balancer.Default = strategy
+	}
+	if enabled := os.Getenv("HEALTH_CHECK_ENABLED"); enabled != "" {
+		if b, err := strconv.ParseBool(enabled); err == nil {
+			cfg.Routing.HealthCheck.Enabled = b
+		}
+	}
+	if interval := os.Getenv("HEALTH_CHECK_INTERVAL"); interval != "" {
+		if d, err := time.ParseDuration(interval); err == nil {
+			cfg.Routing.HealthCheck.Interval = d
+		}
+	}
+
+	if ttl := os.Getenv("CLIENT_SESSION_TTL"); ttl != "" {
+		if d, err := time.ParseDuration(ttl); err == nil {
+			cfg.Routing.Sessions.ClientTTL = d
+		}
+	}
+	if interval := os.Getenv("SESSION_REAP_INTERVAL"); interval != "" {
+		if d, err := time.ParseDuration(interval); err == nil {
+			cfg.Routing.Sessions.ReapInterval = d
+		}
+	}
+
+	// Stream ticket
+	if secret := os.Getenv("STREAM_TICKET_SECRET"); secret != "" {
+		cfg.StreamTicket.Secret = secret
+	}
+	if keyID := os.Getenv("STREAM_TICKET_KEY_ID"); keyID != "" {
+		cfg.StreamTicket.KeyID = keyID
+	}
+	if ttl := os.Getenv("STREAM_TICKET_TTL"); ttl != "" {
+		if d, err := time.ParseDuration(ttl); err == nil {
+			cfg.StreamTicket.TTL = d
+		}
+	}
+
 	// Server
 	if host := os.Getenv("SERVER_HOST"); host != "" {
 		cfg.Server.Host = host
@@ -221,12 +738,93 @@ func Default() *PreConfig {
 			Password: "postgres",
 			Name:     "user_service",
 			SSLMode:  "disable",
+
+			MaxConnections:     10,
+			MaxIdleConnections: 5,
+			ConnMaxLifetime:    30 * time.Minute,
+			ConnMaxIdleTime:    5 * time.Minute,
+
+			ConnectRetries:    5,
+			ConnectBackoff:    time.Second,
+			MaxConnectBackoff: 30 * time.Second,
 		},
 		Redis: RedisConfig{
 			Host: "localhost",
 			Port: 6379,
 			DB:   0,
 		},
+		TLS: TLSConfig{
+			ClientAuth:     TLSClientAuthNone,
+			ReloadInterval: 30 * time.Second,
+		},
+		Auth: AuthConfig{
+			Mode:            AuthModeOpaque,
+			TokenTTL:        24 * time.Hour,
+			RefreshTokenTTL: 30 * 24 * time.Hour,
+			ServiceTokenTTL: time.Hour,
+			Throttle: LoginThrottleConfig{
+				Enabled:         true,
+				Store:           ThrottleStoreMemory,
+				Window:          15 * time.Minute,
+				FreeAttempts:    3,
+				BaseDelay:       time.Second,
+				MaxDelay:        30 * time.Second,
+				MaxUserFailures: 10,
+				MaxIPFailures:   50,
+				LockoutDuration: 15 * time.Minute,
+			},
+			APIKeys: APIKeyConfig{
+				DefaultTTL: 90 * 24 * time.Hour,
+				MaxTTL:     365 * 24 * time.Hour,
+			},
+		},
+		Password: PasswordConfig{
+			Algorithm:         "argon2id",
+			BcryptCost:        12,
+			Argon2Memory:      64 * 1024,
+			Argon2Iterations:  3,
+			Argon2Parallelism: 2,
+		},
+		RBAC: RBACConfig{
+			CacheTTL: time.Minute,
+		},
+		Routing: RoutingConfig{
+			Balancer: BalancerConfig{
+				Default:      BalancerLeastLoad,
+				HashReplicas: 100,
+			},
+			HealthCheck: HealthCheckConfig{
+				Enabled:         true,
+				Interval:        10 * time.Second,
+				Timeout:         2 * time.Second,
+				Concurrency:     8,
+				Protocol:        HealthCheckGRPC,
+				HTTPPath:        "/health",
+				DegradedLatency: 500 * time.Millisecond,
+				HealthyAfter:    2,
+				DegradedAfter:   2,
+				UnhealthyAfter:  3,
+			},
+			Sessions: SessionConfig{
+				ClientTTL:    90 * time.Second,
+				ReapInterval: 30 * time.Second,
+				ReapBatch:    500,
+				DrainTimeout: 30 * time.Minute,
+			},
+			Entitlements: map[string]EntitlementConfig{
+				"free":    {MaxBitrate: 2500, MaxResolution: 720},
+				"basic":   {MaxBitrate: 5000, MaxResolution: 1080},
+				"premium": {MaxBitrate: 10000, MaxResolution: 1440},
+			},
+		},
+		JWT: JWTConfig{
+			Expiration: 15 * time.Minute,
+			Algorithm:  "HS256",
+			Issuer:     "user-service",
+		},
+		StreamTicket: StreamTicketConfig{
+			TTL: 2 * time.Minute,
+		},
 		Log: LogConfig{
 			Level:  "info",
 			Format: "json",
@@ -0,0 +1,269 @@
+package handler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/haqury/helpy"
+
+	"github.com/haqury/user-service/internal/models"
+	"github.com/haqury/user-service/internal/service"
+	pb "github.com/haqury/user-service/pkg/gen"
+)
+
+// toPBUser преобразует модель пользователя в protobuf
+func toPBUser(user *models.User) *pb.User {
+	if user == nil {
+		return nil
+	}
+
+	return &pb.User{
+		Id:               user.ID,
+		Username:         user.Username,
+		Email:            user.Email,
+		Phone:            user.Phone,
+		Status:           user.Status,
+		CreatedAt:        user.CreatedAt.Unix(),
+		UpdatedAt:        user.UpdatedAt.Unix(),
+		IsActive:         user.IsActive,
+		Roles:            user.Roles,
+		SubscriptionTier: user.SubscriptionTier,
+		Region:           user.Region,
+		Settings:         toPBUserSettings(user),
+		StreamingConfig:  toPBUserStreamingConfig(user),
+		Stats:            toPBUserStats(user),
+		Metadata:         toPBMetadata(user.Metadata),
+	}
+}
+
+func toPBUserSettings(user *models.User) *pb.User_UserSettings {
+	var settings models.UserSettings
+	if err := user.Settings.Decode(&settings); err != nil {
+		return nil
+	}
+
+	return &pb.User_UserSettings{
+		DefaultQuality:       settings.DefaultQuality,
+		MaxParallelStreams:   settings.MaxParallelStreams,
+		AutoStartRecording:   settings.AutoStartRecording,
+		NotificationsEnabled: settings.NotificationsEnabled,
+		Timezone:             settings.Timezone,
+		Language:             settings.Language,
+	}
+}
+
+func toPBUserStreamingConfig(user *models.User) *pb.User_StreamingConfig {
+	var config models.StreamingConfig
+	if err := user.StreamingConfig.Decode(&config); err != nil {
+		return nil
+	}
+
+	return &pb.User_StreamingConfig{
+		ServerUrl:      config.ServerURL,
+		ServerPort:     config.ServerPort,
+		UseSsl:         config.UseSSL,
+		StreamEndpoint: config.StreamEndpoint,
+		MaxBitrate:     config.MaxBitrate,
+		MaxResolution:  config.MaxResolution,
+		Codec:          config.Codec,
+	}
+}
+
+func toPBUserStats(user *models.User) *pb.User_UserStats {
+	var stats models.UserStats
+	if err := user.Stats.Decode(&stats); err != nil {
+		return nil
+	}
+
+	// Колонки last_login/last_activity точнее, чем значения в JSONB
+	if user.LastLogin != nil {
+		stats.LastLogin = user.LastLogin.Unix()
+	}
+	if user.LastActivity != nil {
+		stats.LastActivity = user.LastActivity.Unix()
+	}
+
+	return &pb.User_UserStats{
+		TotalStreams:     stats.TotalStreams,
+		TotalDuration:    stats.TotalDuration,
+		TotalStorageUsed: stats.TotalStorageUsed,
+		CurrentStreams:   stats.CurrentStreams,
+		SuccessfulLogins: stats.SuccessfulLogins,
+		FailedLogins:     stats.FailedLogins,
+		LastLogin:        stats.LastLogin,
+		LastActivity:     stats.LastActivity,
+	}
+}
+
+func toPBMetadata(metadata models.JSONB) map[string]string {
+	if len(metadata) == 0 {
+		return nil
+	}
+
+	result := make(map[string]string, len(metadata))
+	for k, v := range metadata {
+		if s, ok := v.(string); ok {
+			result[k] = s
+			continue
+		}
+		result[k] = fmt.Sprint(v)
+	}
+	return result
+}
+
+// applyPBUser переносит заполненные поля из protobuf в модель (частичное обновление)
+func applyPBUser(user *models.User, update *pb.User) error {
+	if update == nil {
+		return nil
+	}
+
+	if update.Email != "" {
+		user.Email = update.Email
+	}
+	if update.Phone != "" {
+		user.Phone = update.Phone
+	}
+	if update.Status != "" {
+		user.Status = update.Status
+	}
+	if len(update.Roles) > 0 {
+		user.Roles = update.Roles
+	}
+	if update.SubscriptionTier != "" {
+		user.SubscriptionTier = update.SubscriptionTier
+	}
+	if update.Region != "" {
+		user.Region = update.Region
+	}
+
+	if update.Settings != nil {
+		settings, err := models.ToJSONB(models.UserSettings{
+			DefaultQuality:       update.Settings.DefaultQuality,
+			MaxParallelStreams:   update.Settings.MaxParallelStreams,
+			AutoStartRecording:   update.Settings.AutoStartRecording,
+			NotificationsEnabled: update.Settings.NotificationsEnabled,
+			Timezone:             update.Settings.Timezone,
+			Language:             update.Settings.Language,
+		})
+		if err != nil {
+			return fmt.Errorf("invalid settings: %w", err)
+		}
+		user.Settings = settings
+	}
+
+	if len(update.Metadata) > 0 {
+		if user.Metadata == nil {
+			user.Metadata = make(models.JSONB, len(update.Metadata))
+		}
+		for k, v := range update.Metadata {
+			user.Metadata[k] = v
+		}
+	}
+
+	return nil
+}
+
+// changesPrivilegedFields - обновление затрагивает поля, которые пользователь
+// не может менять себе сам
+func changesPrivilegedFields(update *pb.User) bool {
+	if update == nil {
+		return false
+	}
+	return update.Status != "" || len(update.Roles) > 0 || update.SubscriptionTier != ""
+}
+
+// toPBLoginResponse преобразует результат выдачи токенов в protobuf
+func toPBLoginResponse(result *service.LoginResult) *pb.LoginResponse {
+	return &pb.LoginResponse{
+		Token:        result.AccessToken,
+		User:         toPBUser(result.User),
+		ExpiresAt:    result.ExpiresAt.Unix(),
+		RefreshToken: result.RefreshToken,
+	}
+}
+
+func toPBSession(session *models.Session) *pb.Session {
+	return &pb.Session{
+		Id:         session.ID,
+		UserId:     session.UserID,
+		TokenType:  session.TokenType,
+		ClientInfo: toPBMetadata(session.ClientInfo),
+		UserAgent:  session.UserAgent,
+		IpAddress:  session.IPAddress,
+		CreatedAt:  session.CreatedAt.Unix(),
+		LastUsedAt: session.LastUsedAt.Unix(),
+		ExpiresAt:  session.ExpiresAt.Unix(),
+	}
+}
+
+func toPBRole(role *models.Role) *pb.Role {
+	return &pb.Role{
+		Name:        role.Name,
+		Description: role.Description,
+		Permissions: role.Permissions,
+		CreatedAt:   role.CreatedAt.Unix(),
+		UpdatedAt:   role.UpdatedAt.Unix(),
+	}
+}
+
+func toPBServiceAccount(account *models.ServiceAccount) *pb.ServiceAccount {
+	pbAccount := &pb.ServiceAccount{
+		Id:          account.ID,
+		Name:        account.Name,
+		Description: account.Description,
+		ClientId:    account.ClientID,
+		TlsIdentity: account.TLSIdentity,
+		IsActive:    account.IsActive,
+		CreatedAt:   account.CreatedAt.Unix(),
+	}
+	if account.LastUsedAt != nil {
+		pbAccount.LastUsedAt = account.LastUsedAt.Unix()
+	}
+	return pbAccount
+}
+
+func toPBAPIKey(key *models.APIKey) *pb.APIKey {
+	pbKey := &pb.APIKey{
+		Id:        key.ID,
+		UserId:    key.UserID,
+		Name:      key.Name,
+		Prefix:    key.Prefix,
+		Scopes:    key.Scopes,
+		ClientId:  key.ClientID,
+		CreatedAt: key.CreatedAt.Unix(),
+		ExpiresAt: key.ExpiresAt.Unix(),
+	}
+	if key.LastUsedAt != nil {
+		pbKey.LastUsedAt = key.LastUsedAt.Unix()
+	}
+	return pbKey
+}
+
+// toClientCapabilities преобразует возможности клиента из запроса (nil - не переданы)
+func toClientCapabilities(caps *pb.ClientCapabilities) *models.ClientCapabilities {
+	if caps == nil {
+		return nil
+	}
+	return &models.ClientCapabilities{
+		Codecs:        caps.Codecs,
+		MaxResolution: caps.MaxResolution,
+		BandwidthKbps: caps.BandwidthKbps,
+	}
+}
+
+// unixOrZero возвращает unix-время или 0, если время не задано
+func unixOrZero(t *time.Time) int64 {
+	if t == nil {
+		return 0
+	}
+	return t.Unix()
+}
+
+// newAPIResponse формирует успешный helpy.ApiResponse
+func newAPIResponse(message string) *helpy.ApiResponse {
+	return &helpy.ApiResponse{
+		Status:    "success",
+		Message:   message,
+		Timestamp: time.Now().Unix(),
+	}
+}
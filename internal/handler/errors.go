@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/haqury/user-service/internal/auth"
+	"github.com/haqury/user-service/internal/repository"
+	"github.com/haqury/user-service/internal/service"
+	"github.com/haqury/user-service/internal/throttle"
+)
+
+// toStatusError преобразует ошибки слоев service/repository в gRPC статус
+func toStatusError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	var throttled *throttle.ThrottledError
+	if errors.As(err, &throttled) {
+		return throttledStatus(throttled)
+	}
+
+	switch {
+	case errors.Is(err, service.ErrInvalidCredentials), errors.Is(err, service.ErrInvalidToken),
+		errors.Is(err, service.ErrRefreshTokenReused), errors.Is(err, auth.ErrMissingToken),
+		errors.Is(err, service.ErrInvalidAPIKey):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, service.ErrInvalidPassword), errors.Is(err, service.ErrInvalidRoleName),
+		errors.Is(err, service.ErrInvalidPermission), errors.Is(err, service.ErrInvalidServiceAccountName),
+		errors.Is(err, service.ErrInvalidAPIKeyScope), errors.Is(err, service.ErrInvalidAPIKeyName),
+		errors.Is(err, service.ErrInvalidAPIKeyTTL), errors.Is(err, service.ErrInvalidInstanceLoad),
+		errors.Is(err, service.ErrInvalidDrainBatchSize), errors.Is(err, service.ErrInvalidInstanceState),
+		errors.Is(err, service.ErrInvalidClientCapabilities):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, service.ErrUserInactive), errors.Is(err, service.ErrAPIKeyScopeDenied):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, repository.ErrInstanceStateTransition), errors.Is(err, service.ErrNoCompatibleInstance):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, service.ErrNoAvailableInstance):
+		return status.Error(codes.Unavailable, err.Error())
+	case errors.Is(err, repository.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, repository.ErrAlreadyExists):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	default:
+		// Текст внутренних ошибок (SQL, драйвер) остается в логе и не уходит клиенту
+		log.Printf("Internal error: %v", err)
+		return status.Error(codes.Internal, "internal error")
+	}
+}
+
+// throttledStatus - блокировка входа дает PermissionDenied, задержка между попытками -
+// ResourceExhausted; в обоих случаях клиент получает RetryInfo
+func throttledStatus(err *throttle.ThrottledError) error {
+	code := codes.ResourceExhausted
+	if errors.Is(err, throttle.ErrLockedOut) {
+		code = codes.PermissionDenied
+	}
+
+	st, detailsErr := status.New(code, err.Error()).WithDetails(&errdetails.RetryInfo{
+		RetryDelay: durationpb.New(err.RetryAfter),
+	})
+	if detailsErr != nil {
+		return status.Error(code, err.Error())
+	}
+	return st.Err()
+}
@@ -2,10 +2,18 @@ package handler
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"strconv"
+	"time"
 
-	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/haqury/helpy"
+	"github.com/haqury/user-service/internal/auth"
+	"github.com/haqury/user-service/internal/models"
 	"github.com/haqury/user-service/internal/service"
 	pb "github.com/haqury/user-service/pkg/gen"
 )
@@ -14,13 +22,36 @@ import (
 type UserServiceServer struct {
 	pb.UnimplementedUserServiceServer
 	userService    service.UserService
+	authService    service.AuthService
 	routingService service.RoutingService
+	rbacService    service.RBACService
+	accountService service.ServiceAccountService
+	apiKeyService  service.APIKeyService
+	instances      service.InstanceService
+
+	// trustedProxies - прокси, которым доверяется x-forwarded-for при определении IP клиента
+	trustedProxies []*net.IPNet
 }
 
-func NewUserServiceServer(userService service.UserService, routingService service.RoutingService) *UserServiceServer {
+func NewUserServiceServer(
+	userService service.UserService,
+	authService service.AuthService,
+	routingService service.RoutingService,
+	rbacService service.RBACService,
+	accountService service.ServiceAccountService,
+	apiKeyService service.APIKeyService,
+	instances service.InstanceService,
+	trustedProxies []*net.IPNet,
+) *UserServiceServer {
 	return &UserServiceServer{
 		userService:    userService,
+		authService:    authService,
 		routingService: routingService,
+		rbacService:    rbacService,
+		accountService: accountService,
+		apiKeyService:  apiKeyService,
+		instances:      instances,
+		trustedProxies: trustedProxies,
 	}
 }
 
@@ -28,37 +59,27 @@ func NewUserServiceServer(userService service.UserService, routingService servic
 func (s *UserServiceServer) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.User, error) {
 	user, err := s.userService.GetUser(ctx, req.UserId)
 	if err != nil {
-		return nil, err
+		return nil, toStatusError(err)
 	}
 
-	// TODO: преобразовать user из interface{} в *pb.User
-	_ = user
-	return &pb.User{
-		Id:       req.UserId,
-		Username: "test",
-		Email:    "test@example.com",
-	}, nil
+	return toPBUser(user), nil
 }
 
 // GetUserByUsername получает пользователя по имени
 func (s *UserServiceServer) GetUserByUsername(ctx context.Context, req *pb.GetUserByUsernameRequest) (*pb.User, error) {
 	user, err := s.userService.GetUserByUsername(ctx, req.Username)
 	if err != nil {
-		return nil, err
+		return nil, toStatusError(err)
 	}
 
-	// TODO: преобразовать user из interface{} в *pb.User
-	_ = user
-	return &pb.User{
-		Username: req.Username,
-	}, nil
+	return toPBUser(user), nil
 }
 
 // GetUserByClientId получает информацию о пользователе по client_id
 func (s *UserServiceServer) GetUserByClientId(ctx context.Context, req *pb.GetUserByClientIdRequest) (*pb.GetUserByClientIdResponse, error) {
 	userInfo, err := s.userService.GetUserByClientID(ctx, req.ClientId)
 	if err != nil {
-		return nil, err
+		return nil, toStatusError(err)
 	}
 
 	return &pb.GetUserByClientIdResponse{
@@ -71,79 +92,515 @@ func (s *UserServiceServer) GetUserByClientId(ctx context.Context, req *pb.GetUs
 
 // CreateUser создает нового пользователя
 func (s *UserServiceServer) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.User, error) {
-	// TODO: implement
-	return nil, nil
+	if req.Username == "" || req.Email == "" {
+		return nil, status.Error(codes.InvalidArgument, "username and email are required")
+	}
+
+	user := &models.User{
+		Username: req.Username,
+		Email:    req.Email,
+		Phone:    req.Phone,
+		IsActive: true,
+	}
+	if err := s.userService.CreateUser(ctx, user, req.Password); err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return toPBUser(user), nil
 }
 
 // UpdateUser обновляет пользователя
 func (s *UserServiceServer) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest) (*pb.User, error) {
-	// TODO: implement
-	return nil, nil
+	if changesPrivilegedFields(req.User) && !isAdmin(ctx) {
+		return nil, status.Error(codes.PermissionDenied, "only admin can change status, roles or subscription tier")
+	}
+
+	// Роли записываются списком целиком, поэтому каждая проверяется так же, как в AssignRole
+	if req.User != nil && len(req.User.Roles) > 0 {
+		if err := s.rbacService.ValidateRoles(ctx, req.User.Roles); err != nil {
+			return nil, toStatusError(err)
+		}
+	}
+
+	user, err := s.userService.GetUser(ctx, req.UserId)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	if err := applyPBUser(user, req.User); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if err := s.userService.UpdateUser(ctx, req.UserId, user); err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return toPBUser(user), nil
 }
 
 // DeleteUser удаляет пользователя
 func (s *UserServiceServer) DeleteUser(ctx context.Context, req *pb.DeleteUserRequest) (*helpy.ApiResponse, error) {
-	// TODO: implement
-	return nil, nil
+	if err := s.userService.DeleteUser(ctx, req.UserId); err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return newAPIResponse("user deleted"), nil
 }
 
 // ListUsers возвращает список пользователей
 func (s *UserServiceServer) ListUsers(ctx context.Context, req *pb.ListUsersRequest) (*pb.ListUsersResponse, error) {
-	// TODO: implement
-	return nil, nil
+	page, limit := int(req.Page), int(req.Limit)
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	users, total, err := s.userService.ListUsers(ctx, page, limit, req.Filter)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	resp := &pb.ListUsersResponse{
+		Users:      make([]*pb.User, 0, len(users)),
+		Total:      int32(total),
+		Page:       int32(page),
+		TotalPages: int32((total + limit - 1) / limit),
+	}
+	for _, user := range users {
+		resp.Users = append(resp.Users, toPBUser(user))
+	}
+
+	return resp, nil
 }
 
 // Login аутентифицирует пользователя
 func (s *UserServiceServer) Login(ctx context.Context, req *pb.LoginRequest) (*pb.LoginResponse, error) {
-	// TODO: implement
-	return nil, nil
+	if req.Username == "" || req.Password == "" {
+		return nil, status.Error(codes.InvalidArgument, "username and password are required")
+	}
+
+	var clientInfo models.JSONB
+	if req.ClientInfo != "" {
+		if err := json.Unmarshal([]byte(req.ClientInfo), &clientInfo); err != nil {
+			return nil, status.Error(codes.InvalidArgument, "client_info must be a JSON object")
+		}
+	}
+
+	result, err := s.authService.Login(ctx, service.LoginParams{
+		Username:   req.Username,
+		Password:   req.Password,
+		ClientInfo: clientInfo,
+		UserAgent:  userAgentFromContext(ctx),
+		IPAddress:  clientIPFromContext(ctx, s.trustedProxies),
+	})
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return toPBLoginResponse(result), nil
+}
+
+// RefreshToken обменивает refresh токен на новую пару токенов
+func (s *UserServiceServer) RefreshToken(ctx context.Context, req *pb.RefreshTokenRequest) (*pb.LoginResponse, error) {
+	if req.RefreshToken == "" {
+		return nil, status.Error(codes.InvalidArgument, "refresh_token is required")
+	}
+
+	result, err := s.authService.RefreshToken(ctx, req.RefreshToken)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return toPBLoginResponse(result), nil
+}
+
+// IssueServiceToken выдает токен сервисному аккаунту (client credentials)
+func (s *UserServiceServer) IssueServiceToken(ctx context.Context, req *pb.ServiceTokenRequest) (*pb.ServiceTokenResponse, error) {
+	if req.ClientId == "" || req.ClientSecret == "" {
+		return nil, status.Error(codes.InvalidArgument, "client_id and client_secret are required")
+	}
+
+	result, err := s.authService.IssueServiceToken(ctx, req.ClientId, req.ClientSecret)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &pb.ServiceTokenResponse{
+		AccessToken: result.AccessToken,
+		TokenType:   "Bearer",
+		ExpiresAt:   result.ExpiresAt.Unix(),
+	}, nil
 }
 
 // ValidateToken проверяет токен
 func (s *UserServiceServer) ValidateToken(ctx context.Context, req *pb.ValidateTokenRequest) (*pb.ValidateTokenResponse, error) {
-	// TODO: implement
-	return nil, nil
+	user, err := s.authService.ValidateToken(ctx, req.Token)
+	if errors.Is(err, service.ErrInvalidToken) || errors.Is(err, service.ErrUserInactive) {
+		return &pb.ValidateTokenResponse{
+			Valid:   false,
+			Message: err.Error(),
+		}, nil
+	}
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &pb.ValidateTokenResponse{
+		Valid: true,
+		User:  toPBUser(user),
+	}, nil
 }
 
 // Logout выполняет выход пользователя
-func (s *UserServiceServer) Logout(ctx context.Context, req *emptypb.Empty) (*helpy.ApiResponse, error) {
-	// TODO: implement
-	return nil, nil
+func (s *UserServiceServer) Logout(ctx context.Context, req *pb.LogoutRequest) (*helpy.ApiResponse, error) {
+	token, err := auth.TokenFromContext(ctx)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	if err := s.authService.Logout(ctx, token, req.RevokeFamily); err != nil {
+		return nil, toStatusError(err)
+	}
+
+	if req.RevokeFamily {
+		return newAPIResponse("session revoked"), nil
+	}
+	return newAPIResponse("logged out"), nil
+}
+
+// ListSessions возвращает активные сессии пользователя
+func (s *UserServiceServer) ListSessions(ctx context.Context, req *pb.ListSessionsRequest) (*pb.ListSessionsResponse, error) {
+	sessions, err := s.authService.ListSessions(ctx, req.UserId)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	resp := &pb.ListSessionsResponse{
+		Sessions: make([]*pb.Session, 0, len(sessions)),
+	}
+	for _, session := range sessions {
+		resp.Sessions = append(resp.Sessions, toPBSession(session))
+	}
+
+	return resp, nil
+}
+
+// RevokeSession завершает одну сессию пользователя
+func (s *UserServiceServer) RevokeSession(ctx context.Context, req *pb.RevokeSessionRequest) (*helpy.ApiResponse, error) {
+	if err := s.authService.RevokeSession(ctx, req.UserId, req.SessionId); err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return newAPIResponse("session revoked"), nil
+}
+
+// RevokeAllSessions завершает все сессии пользователя
+func (s *UserServiceServer) RevokeAllSessions(ctx context.Context, req *pb.RevokeAllSessionsRequest) (*helpy.ApiResponse, error) {
+	revoked, err := s.authService.RevokeAllSessions(ctx, req.UserId)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	resp := newAPIResponse("all sessions revoked")
+	resp.Metadata = map[string]string{"revoked": strconv.Itoa(revoked)}
+	return resp, nil
+}
+
+// CreateRole создает роль с набором прав
+func (s *UserServiceServer) CreateRole(ctx context.Context, req *pb.CreateRoleRequest) (*pb.Role, error) {
+	role := &models.Role{
+		Name:        req.Name,
+		Description: req.Description,
+		Permissions: req.Permissions,
+	}
+	if err := s.rbacService.CreateRole(ctx, role); err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return toPBRole(role), nil
+}
+
+// ListRoles возвращает все роли с их правами
+func (s *UserServiceServer) ListRoles(ctx context.Context, req *pb.ListRolesRequest) (*pb.ListRolesResponse, error) {
+	roles, err := s.rbacService.ListRoles(ctx)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	resp := &pb.ListRolesResponse{
+		Roles: make([]*pb.Role, 0, len(roles)),
+	}
+	for _, role := range roles {
+		resp.Roles = append(resp.Roles, toPBRole(role))
+	}
+
+	return resp, nil
+}
+
+// GrantPermission выдает право роли
+func (s *UserServiceServer) GrantPermission(ctx context.Context, req *pb.RolePermissionRequest) (*helpy.ApiResponse, error) {
+	if err := s.rbacService.GrantPermission(ctx, req.Role, req.Permission); err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return newAPIResponse("permission granted"), nil
+}
+
+// RevokePermission отзывает право у роли
+func (s *UserServiceServer) RevokePermission(ctx context.Context, req *pb.RolePermissionRequest) (*helpy.ApiResponse, error) {
+	if err := s.rbacService.RevokePermission(ctx, req.Role, req.Permission); err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return newAPIResponse("permission revoked"), nil
+}
+
+// AssignRole назначает роль пользователю
+func (s *UserServiceServer) AssignRole(ctx context.Context, req *pb.UserRoleRequest) (*pb.User, error) {
+	user, err := s.rbacService.AssignRole(ctx, req.UserId, req.Role)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return toPBUser(user), nil
+}
+
+// RemoveRole снимает роль с пользователя
+func (s *UserServiceServer) RemoveRole(ctx context.Context, req *pb.UserRoleRequest) (*pb.User, error) {
+	user, err := s.rbacService.RemoveRole(ctx, req.UserId, req.Role)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return toPBUser(user), nil
+}
+
+// CheckPermission проверяет, есть ли у пользователя право
+func (s *UserServiceServer) CheckPermission(ctx context.Context, req *pb.CheckPermissionRequest) (*pb.CheckPermissionResponse, error) {
+	allowed, err := s.rbacService.HasPermission(ctx, req.UserId, req.Permission)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &pb.CheckPermissionResponse{Allowed: allowed}, nil
+}
+
+// CreateServiceAccount создает сервисный аккаунт; client_secret возвращается один раз
+func (s *UserServiceServer) CreateServiceAccount(ctx context.Context, req *pb.CreateServiceAccountRequest) (*pb.CreateServiceAccountResponse, error) {
+	account := &models.ServiceAccount{
+		Name:        req.Name,
+		Description: req.Description,
+		TLSIdentity: req.TlsIdentity,
+	}
+	secret, err := s.accountService.Create(ctx, account)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &pb.CreateServiceAccountResponse{
+		Account:      toPBServiceAccount(account),
+		ClientSecret: secret,
+	}, nil
+}
+
+// ListServiceAccounts возвращает сервисные аккаунты
+func (s *UserServiceServer) ListServiceAccounts(ctx context.Context, req *pb.ListServiceAccountsRequest) (*pb.ListServiceAccountsResponse, error) {
+	accounts, err := s.accountService.List(ctx)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	resp := &pb.ListServiceAccountsResponse{
+		Accounts: make([]*pb.ServiceAccount, 0, len(accounts)),
+	}
+	for _, account := range accounts {
+		resp.Accounts = append(resp.Accounts, toPBServiceAccount(account))
+	}
+
+	return resp, nil
+}
+
+// DeleteServiceAccount удаляет сервисный аккаунт и отзывает его токены
+func (s *UserServiceServer) DeleteServiceAccount(ctx context.Context, req *pb.DeleteServiceAccountRequest) (*helpy.ApiResponse, error) {
+	if err := s.accountService.Delete(ctx, req.Id); err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return newAPIResponse("service account deleted"), nil
+}
+
+// CreateAPIKey создает API ключ пользователя; значение ключа возвращается один раз
+func (s *UserServiceServer) CreateAPIKey(ctx context.Context, req *pb.CreateAPIKeyRequest) (*pb.CreateAPIKeyResponse, error) {
+	if req.TtlSeconds < 0 {
+		return nil, toStatusError(service.ErrInvalidAPIKeyTTL)
+	}
+
+	key, value, err := s.apiKeyService.Create(ctx, service.CreateAPIKeyParams{
+		UserID: req.UserId,
+		Name:   req.Name,
+		Scopes: req.Scopes,
+		TTL:    time.Duration(req.TtlSeconds) * time.Second,
+	})
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &pb.CreateAPIKeyResponse{
+		Key:    toPBAPIKey(key),
+		ApiKey: value,
+	}, nil
+}
+
+// ListAPIKeys возвращает действующие API ключи пользователя
+func (s *UserServiceServer) ListAPIKeys(ctx context.Context, req *pb.ListAPIKeysRequest) (*pb.ListAPIKeysResponse, error) {
+	keys, err := s.apiKeyService.List(ctx, req.UserId)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	resp := &pb.ListAPIKeysResponse{
+		Keys: make([]*pb.APIKey, 0, len(keys)),
+	}
+	for _, key := range keys {
+		resp.Keys = append(resp.Keys, toPBAPIKey(key))
+	}
+
+	return resp, nil
+}
+
+// RevokeAPIKey отзывает API ключ пользователя
+func (s *UserServiceServer) RevokeAPIKey(ctx context.Context, req *pb.RevokeAPIKeyRequest) (*helpy.ApiResponse, error) {
+	if err := s.apiKeyService.Revoke(ctx, req.UserId, req.KeyId); err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return newAPIResponse("api key revoked"), nil
+}
+
+// VerifyAPIKey проверяет ключ, с которым клиент подключает поток к video-service
+func (s *UserServiceServer) VerifyAPIKey(ctx context.Context, req *pb.VerifyAPIKeyRequest) (*pb.VerifyAPIKeyResponse, error) {
+	verified, err := s.apiKeyService.Verify(ctx, req.ApiKey, req.Scope)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &pb.VerifyAPIKeyResponse{
+		Key:              toPBAPIKey(verified.Key),
+		SubscriptionTier: verified.User.SubscriptionTier,
+		Region:           verified.User.Region,
+	}, nil
+}
+
+// HeartbeatStreamingSession продлевает сессию стриминга клиента
+func (s *UserServiceServer) HeartbeatStreamingSession(ctx context.Context, req *pb.StreamingSessionRequest) (*helpy.ApiResponse, error) {
+	if err := s.routingService.TouchStreamingSession(ctx, req.UserId, req.ClientId); err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return newAPIResponse("streaming session extended"), nil
+}
+
+// ReleaseStreamingSession завершает сессию стриминга клиента
+func (s *UserServiceServer) ReleaseStreamingSession(ctx context.Context, req *pb.StreamingSessionRequest) (*helpy.ApiResponse, error) {
+	if err := s.routingService.ReleaseStreamingSession(ctx, req.UserId, req.ClientId); err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return newAPIResponse("streaming session released"), nil
+}
+
+// ReportInstanceLoad принимает heartbeat инстанса video-service с его нагрузкой
+func (s *UserServiceServer) ReportInstanceLoad(ctx context.Context, req *pb.ReportInstanceLoadRequest) (*pb.ReportInstanceLoadResponse, error) {
+	instance, err := s.instances.Heartbeat(ctx, req.InstanceId, req.CurrentLoad, req.MaxCapacity)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &pb.ReportInstanceLoadResponse{
+		InstanceId:    instance.ID,
+		CurrentLoad:   instance.CurrentLoad,
+		MaxCapacity:   instance.MaxCapacity,
+		HealthStatus:  instance.HealthStatus,
+		IsActive:      instance.IsActive,
+		State:         instance.State,
+		DrainDeadline: unixOrZero(instance.DrainDeadline),
+	}, nil
+}
+
+// DrainInstance выводит инстанс из роутинга и переносит его клиентов
+func (s *UserServiceServer) DrainInstance(ctx context.Context, req *pb.DrainInstanceRequest) (*pb.DrainInstanceResponse, error) {
+	result, err := s.routingService.DrainInstance(ctx, req.InstanceId, int(req.BatchSize))
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &pb.DrainInstanceResponse{
+		InstanceId: req.InstanceId,
+		Migrated:   int32(result.Migrated),
+		Remaining:  int32(result.Remaining),
+	}, nil
+}
+
+// SetInstanceState меняет состояние жизненного цикла инстанса
+func (s *UserServiceServer) SetInstanceState(ctx context.Context, req *pb.SetInstanceStateRequest) (*pb.SetInstanceStateResponse, error) {
+	drainTimeout := time.Duration(req.DrainTimeoutSeconds) * time.Second
+	instance, err := s.routingService.SetInstanceState(ctx, req.InstanceId, req.State, drainTimeout)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &pb.SetInstanceStateResponse{
+		InstanceId:    instance.ID,
+		State:         instance.State,
+		DrainDeadline: unixOrZero(instance.DrainDeadline),
+		CurrentLoad:   instance.CurrentLoad,
+	}, nil
 }
 
 // GetStreamingConfig получает конфигурацию стриминга для пользователя
 func (s *UserServiceServer) GetStreamingConfig(ctx context.Context, req *pb.GetStreamingConfigRequest) (*pb.User_StreamingConfig, error) {
-	config, err := s.routingService.GetStreamingConfigForClient(ctx, req.UserId, req.ClientId)
+	caps := toClientCapabilities(req.Capabilities)
+	config, err := s.routingService.GetStreamingConfigForClient(ctx, req.UserId, req.ClientId, caps)
 	if err != nil {
-		return nil, err
+		return nil, toStatusError(err)
 	}
 
 	return &pb.User_StreamingConfig{
-		ServerUrl:      config.ServerURL,
-		ServerPort:     config.ServerPort,
-		UseSsl:         config.UseSSL,
-		ApiKey:         config.APIKey,
-		StreamEndpoint: config.StreamEndpoint,
-		MaxBitrate:     config.MaxBitrate,
-		MaxResolution:  config.MaxResolution,
-		Codec:          config.Codec,
+		ServerUrl:       config.ServerURL,
+		ServerPort:      config.ServerPort,
+		UseSsl:          config.UseSSL,
+		ApiKey:          config.APIKey,
+		StreamEndpoint:  config.StreamEndpoint,
+		MaxBitrate:      config.MaxBitrate,
+		MaxResolution:   config.MaxResolution,
+		Codec:           config.Codec,
+		StreamTicket:    config.StreamTicket,
+		TicketExpiresAt: config.TicketExpiresAt,
+		Region:          config.Region,
+		FallbackDepth:   config.FallbackDepth,
 	}, nil
 }
 
-// UpdateStreamingConfig обновляет конфигурацию стриминга
+// UpdateStreamingConfig обновляет конфигурацию стриминга (еще не реализован)
 func (s *UserServiceServer) UpdateStreamingConfig(ctx context.Context, req *pb.UpdateUserRequest) (*pb.User_StreamingConfig, error) {
 	// TODO: implement
-	return nil, nil
+	return nil, status.Error(codes.Unimplemented, "UpdateStreamingConfig is not implemented")
 }
 
-// UpdateUserStats обновляет статистику пользователя
+// UpdateUserStats обновляет статистику пользователя (еще не реализован)
 func (s *UserServiceServer) UpdateUserStats(ctx context.Context, req *pb.UpdateUserRequest) (*helpy.ApiResponse, error) {
 	// TODO: implement
-	return nil, nil
+	return nil, status.Error(codes.Unimplemented, "UpdateUserStats is not implemented")
 }
 
 // GetUserStats получает статистику пользователя
 func (s *UserServiceServer) GetUserStats(ctx context.Context, req *pb.GetUserRequest) (*pb.User_UserStats, error) {
-	// TODO: implement
-	return nil, nil
+	user, err := s.userService.GetUser(ctx, req.UserId)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return toPBUserStats(user), nil
 }
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"github.com/haqury/user-service/internal/auth"
+)
+
+// userAgentFromContext возвращает User-Agent клиента. Для запросов через gateway
+// исходный заголовок передается с префиксом grpcgateway-
+func userAgentFromContext(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	for _, key := range []string{"grpcgateway-user-agent", "user-agent"} {
+		if values := md.Get(key); len(values) > 0 && values[0] != "" {
+			return values[0]
+		}
+	}
+	return ""
+}
+
+// ParseTrustedProxies разбирает список CIDR доверенных прокси; адрес без маски
+// считается отдельным хостом
+func ParseTrustedProxies(cidrs []string) ([]*net.IPNet, error) {
+	proxies := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		cidr = strings.TrimSpace(cidr)
+		if cidr == "" {
+			continue
+		}
+		if !strings.Contains(cidr, "/") {
+			ip := net.ParseIP(cidr)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy address: %s", cidr)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy cidr %s: %w", cidr, err)
+		}
+		proxies = append(proxies, network)
+	}
+	return proxies, nil
+}
+
+// clientIPFromContext возвращает IP клиента. x-forwarded-for учитывается только для
+// соединений от доверенных прокси (loopback - встроенный HTTP gateway - доверен всегда).
+// Адреса перебираются справа налево, и берется первый недоверенный: каждый прокси дописывает
+// адрес своего клиента последним, поэтому значения, присланные клиентом, подделать его не могут
+func clientIPFromContext(ctx context.Context, trusted []*net.IPNet) string {
+	peerIP := peerIPFromContext(ctx)
+	if !isTrustedProxy(net.ParseIP(peerIP), trusted) {
+		return peerIP
+	}
+
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return peerIP
+	}
+
+	var hops []string
+	for _, value := range md.Get("x-forwarded-for") {
+		hops = append(hops, strings.Split(value, ",")...)
+	}
+
+	clientIP := peerIP
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		ip := net.ParseIP(hop)
+		if ip == nil {
+			// Дальше цепочки доверия нет: последний проверенный адрес и есть клиент
+			break
+		}
+		clientIP = hop
+		if !isTrustedProxy(ip, trusted) {
+			break
+		}
+	}
+	return clientIP
+}
+
+// isTrustedProxy - соединение с ip установлено доверенным прокси
+func isTrustedProxy(ip net.IP, trusted []*net.IPNet) bool {
+	if ip == nil {
+		return false
+	}
+	if ip.IsLoopback() {
+		return true
+	}
+	for _, network := range trusted {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// peerIPFromContext возвращает IP адрес, с которого установлено gRPC соединение
+func peerIPFromContext(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return ""
+	}
+	return host
+}
+
+// isAdmin - вызывающий аутентифицирован interceptor'ом и имеет роль admin
+func isAdmin(ctx context.Context) bool {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	return ok && principal.IsAdmin()
+}
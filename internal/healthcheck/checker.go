@@ -0,0 +1,194 @@
+// Package healthcheck периодически проверяет активные инстансы video-service и записывает
+// их состояние (healthy, degraded, unhealthy) в video_service_instances, откуда его
+// берет роутинг
+package healthcheck
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/haqury/user-service/internal/config"
+	"github.com/haqury/user-service/internal/models"
+	"github.com/haqury/user-service/internal/repository"
+)
+
+// Checker проверяет инстансы. Счетчики гистерезиса живут в памяти: после рестарта
+// отсчет начинается с состояния, записанного в БД
+type Checker struct {
+	repo   repository.VideoServiceInstanceRepository
+	config config.HealthCheckConfig
+
+	grpc *grpcProber
+	http *httpProber
+
+	mu     sync.Mutex
+	states map[string]*state // по id инстанса
+}
+
+// NewChecker проверяет конфигурацию; ошибка в ней - ошибка старта
+func NewChecker(repo repository.VideoServiceInstanceRepository, cfg config.HealthCheckConfig) (*Checker, error) {
+	if err := validProtocol(cfg.Protocol); err != nil {
+		return nil, err
+	}
+	if cfg.Timeout <= 0 {
+		return nil, errors.New("health check timeout must be positive")
+	}
+	if cfg.HealthyAfter < 1 || cfg.DegradedAfter < 1 || cfg.UnhealthyAfter < 1 {
+		return nil, errors.New("health check thresholds must be at least 1")
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 1
+	}
+
+	return &Checker{
+		repo:   repo,
+		config: cfg,
+		grpc:   newGRPCProber(),
+		http:   newHTTPProber(),
+		states: make(map[string]*state),
+	}, nil
+}
+
+// Run проверяет инстансы сразу и затем каждые Interval, пока не отменен ctx.
+// Возвращается после завершения текущего цикла проверок и закрытия соединений
+func (c *Checker) Run(ctx context.Context) {
+	defer c.grpc.close()
+	defer c.http.close()
+
+	if c.config.Interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(c.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		c.CheckAll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckAll проверяет все активные инстансы, не более Concurrency одновременно
+func (c *Checker) CheckAll(ctx context.Context) {
+	instances, err := c.repo.GetActiveInstances(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("Health check: failed to list instances: %v", err)
+		}
+		return
+	}
+
+	var (
+		wg    sync.WaitGroup
+		sem   = make(chan struct{}, c.config.Concurrency)
+		conns = make(map[string]bool)
+	)
+	for _, instance := range instances {
+		t, err := targetFor(instance, c.config)
+		if err != nil {
+			log.Printf("Health check: instance %s: %v", instance.Name, err)
+			continue
+		}
+		if t.Protocol == config.HealthCheckGRPC {
+			conns[connKey(t)] = true
+		}
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		}
+
+		wg.Add(1)
+		go func(instance *models.VideoServiceInstance, t target) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			c.check(ctx, instance, t)
+		}(instance, t)
+	}
+	wg.Wait()
+
+	c.forget(instances)
+	c.grpc.retain(conns)
+}
+
+// check выполняет одну проверку и записывает результат
+func (c *Checker) check(ctx context.Context, instance *models.VideoServiceInstance, t target) {
+	probeCtx, cancel := context.WithTimeout(ctx, c.config.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := c.probe(probeCtx, t)
+	latency := time.Since(start)
+
+	// Остановка сервиса - не повод считать инстанс недоступным
+	if ctx.Err() != nil {
+		return
+	}
+
+	result := outcomeUp
+	switch {
+	case err != nil:
+		result = outcomeDown
+	case c.config.DegradedLatency > 0 && latency >= c.config.DegradedLatency:
+		result = outcomeSlow
+	}
+
+	previous, status := c.observe(instance, result)
+	if status != previous {
+		log.Printf("Health check: instance %s %s -> %s (latency %s, error: %v)",
+			instance.Name, previous, status, latency.Round(time.Millisecond), err)
+	}
+
+	if err := c.repo.UpdateHealth(ctx, instance.ID, status); err != nil && ctx.Err() == nil {
+		log.Printf("Health check: failed to save status of instance %s: %v", instance.Name, err)
+	}
+}
+
+func (c *Checker) probe(ctx context.Context, t target) error {
+	if t.Protocol == config.HealthCheckHTTP {
+		return c.http.probe(ctx, t)
+	}
+	return c.grpc.probe(ctx, t)
+}
+
+// observe обновляет счетчики инстанса и возвращает прежнее и новое состояние
+func (c *Checker) observe(instance *models.VideoServiceInstance, result outcome) (string, string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s, ok := c.states[instance.ID]
+	if !ok {
+		s = &state{status: instance.HealthStatus}
+		c.states[instance.ID] = s
+	}
+
+	previous := s.status
+	return previous, s.observe(result, c.config)
+}
+
+// forget удаляет счетчики инстансов, которых больше нет среди активных
+func (c *Checker) forget(instances []*models.VideoServiceInstance) {
+	active := make(map[string]bool, len(instances))
+	for _, instance := range instances {
+		active[instance.ID] = true
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for id := range c.states {
+		if !active[id] {
+			delete(c.states, id)
+		}
+	}
+}
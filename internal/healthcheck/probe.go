@@ -0,0 +1,183 @@
+package healthcheck
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/haqury/user-service/internal/config"
+	"github.com/haqury/user-service/internal/models"
+)
+
+// target - что и как проверять у инстанса
+type target struct {
+	Protocol string `json:"protocol"`
+	Address  string `json:"address"` // host:port, по умолчанию server_url:server_port
+	Path     string `json:"path"`    // для http
+	Service  string `json:"service"` // имя сервиса для grpc health, пусто - сервер целиком
+	UseSSL   bool   `json:"-"`
+}
+
+// targetFor собирает цель проверки из настроек по умолчанию и metadata.health_check инстанса
+func targetFor(instance *models.VideoServiceInstance, c config.HealthCheckConfig) (target, error) {
+	t := target{
+		Protocol: c.Protocol,
+		Address:  net.JoinHostPort(instance.ServerURL, strconv.Itoa(int(instance.ServerPort))),
+		Path:     c.HTTPPath,
+		UseSSL:   instance.UseSSL,
+	}
+
+	if raw, ok := instance.Metadata["health_check"].(map[string]interface{}); ok {
+		var override target
+		if err := models.JSONB(raw).Decode(&override); err != nil {
+			return t, fmt.Errorf("invalid metadata.health_check: %w", err)
+		}
+		if override.Protocol != "" {
+			t.Protocol = override.Protocol
+		}
+		if override.Address != "" {
+			t.Address = override.Address
+		}
+		if override.Path != "" {
+			t.Path = override.Path
+		}
+		t.Service = override.Service
+	}
+
+	if err := validProtocol(t.Protocol); err != nil {
+		return t, err
+	}
+	return t, nil
+}
+
+func validProtocol(protocol string) error {
+	switch protocol {
+	case config.HealthCheckGRPC, config.HealthCheckHTTP:
+		return nil
+	default:
+		return fmt.Errorf("unsupported health check protocol: %s", protocol)
+	}
+}
+
+// grpcProber проверяет инстансы по протоколу grpc.health.v1. Соединения переиспользуются
+// между проверками и закрываются, когда инстанс пропадает из списка
+type grpcProber struct {
+	mu    sync.Mutex
+	conns map[string]*grpc.ClientConn
+}
+
+func newGRPCProber() *grpcProber {
+	return &grpcProber{conns: make(map[string]*grpc.ClientConn)}
+}
+
+func (p *grpcProber) probe(ctx context.Context, t target) error {
+	conn, err := p.conn(t)
+	if err != nil {
+		return err
+	}
+
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: t.Service})
+	if err != nil {
+		return err
+	}
+	if resp.Status != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("grpc health status %s", resp.Status)
+	}
+	return nil
+}
+
+func (p *grpcProber) conn(t target) (*grpc.ClientConn, error) {
+	key := connKey(t)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if conn, ok := p.conns[key]; ok {
+		return conn, nil
+	}
+
+	creds := insecure.NewCredentials()
+	if t.UseSSL {
+		creds = credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+	}
+	conn, err := grpc.NewClient(t.Address, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create grpc client: %w", err)
+	}
+
+	p.conns[key] = conn
+	return conn, nil
+}
+
+// retain закрывает соединения, не входящие в keep
+func (p *grpcProber) retain(keep map[string]bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for key, conn := range p.conns {
+		if !keep[key] {
+			conn.Close()
+			delete(p.conns, key)
+		}
+	}
+}
+
+func (p *grpcProber) close() {
+	p.retain(nil)
+}
+
+func connKey(t target) string {
+	return t.Address + "|" + strconv.FormatBool(t.UseSSL)
+}
+
+// httpProber проверяет инстансы запросом GET; успех - код 2xx
+type httpProber struct {
+	client *http.Client
+}
+
+func newHTTPProber() *httpProber {
+	return &httpProber{client: &http.Client{
+		// Редирект на страницу логина или заглушку не означает, что инстанс жив
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}}
+}
+
+func (p *httpProber) probe(ctx context.Context, t target) error {
+	scheme := "http"
+	if t.UseSSL {
+		scheme = "https"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, scheme+"://"+t.Address+t.Path, nil)
+	if err != nil {
+		return fmt.Errorf("failed to build health request: %w", err)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("http health status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (p *httpProber) close() {
+	p.client.CloseIdleConnections()
+}
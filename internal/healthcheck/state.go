@@ -0,0 +1,63 @@
+package healthcheck
+
+import (
+	"github.com/haqury/user-service/internal/config"
+	"github.com/haqury/user-service/internal/models"
+)
+
+// outcome - результат одной проверки
+type outcome int
+
+const (
+	outcomeUp   outcome = iota // ответ успешный и быстрый
+	outcomeSlow                // ответ успешный, но медленнее degraded_latency
+	outcomeDown                // ошибка, таймаут или отрицательный ответ
+)
+
+// state - счетчики подряд идущих результатов инстанса. Состояние меняется только после
+// нескольких одинаковых результатов подряд, поэтому единичный сбой или медленный
+// ответ не переключает роутинг туда и обратно
+type state struct {
+	status string
+	up     int // успешных подряд
+	slow   int // медленных подряд
+	notUp  int // медленных или неуспешных подряд
+	down   int // неуспешных подряд
+}
+
+// observe учитывает результат проверки и возвращает новое состояние
+func (s *state) observe(result outcome, c config.HealthCheckConfig) string {
+	switch result {
+	case outcomeUp:
+		s.up++
+		s.slow, s.notUp, s.down = 0, 0, 0
+		if s.up >= c.HealthyAfter {
+			s.status = models.HealthStatusHealthy
+		}
+
+	case outcomeSlow:
+		s.up, s.down = 0, 0
+		s.slow++
+		s.notUp++
+		switch {
+		case s.status == models.HealthStatusHealthy && s.notUp >= c.DegradedAfter:
+			s.status = models.HealthStatusDegraded
+		case s.status == models.HealthStatusUnhealthy && s.slow >= c.HealthyAfter:
+			// Инстанс снова отвечает, но медленно
+			s.status = models.HealthStatusDegraded
+		}
+
+	case outcomeDown:
+		s.up, s.slow = 0, 0
+		s.notUp++
+		s.down++
+		switch {
+		case s.down >= c.UnhealthyAfter:
+			s.status = models.HealthStatusUnhealthy
+		case s.status == models.HealthStatusHealthy && s.notUp >= c.DegradedAfter:
+			s.status = models.HealthStatusDegraded
+		}
+	}
+
+	return s.status
+}
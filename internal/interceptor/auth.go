@@ -0,0 +1,156 @@
+// Package interceptor содержит gRPC interceptors сервиса
+package interceptor
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/haqury/user-service/internal/auth"
+	"github.com/haqury/user-service/internal/service"
+)
+
+// Authenticator проверяет токен или клиентский сертификат и возвращает вызывающего
+// (реализуется service.AuthService)
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (*auth.Principal, error)
+	AuthenticatePeer(ctx context.Context, identity string) (*auth.Principal, error)
+}
+
+// userIDRequest - запрос, адресованный конкретному пользователю (для PolicySelfOrAdmin)
+type userIDRequest interface {
+	GetUserId() string
+}
+
+// Auth аутентифицирует запросы по bearer токену (пользователи и сервисы) или по
+// клиентскому сертификату mTLS (сервисы) и применяет политики методов
+type Auth struct {
+	authenticator Authenticator
+	policies      Policies
+}
+
+func NewAuth(authenticator Authenticator, policies Policies) *Auth {
+	return &Auth{
+		authenticator: authenticator,
+		policies:      policies,
+	}
+}
+
+// Unary - interceptor для unary RPC
+func (a *Auth) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := a.authorize(ctx, info.FullMethod, req)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// Stream - interceptor для stream RPC. Запрос стрима до вызова handler недоступен,
+// поэтому PolicySelfOrAdmin для стримов требует роль admin
+func (a *Auth) Stream() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := a.authorize(ss.Context(), info.FullMethod, nil)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &principalStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// authorize проверяет токен и политику метода; возвращает контекст с вызывающим
+func (a *Auth) authorize(ctx context.Context, fullMethod string, req interface{}) (context.Context, error) {
+	rule := a.policies.rule(fullMethod)
+	if rule.Policy == PolicyPublic {
+		return ctx, nil
+	}
+
+	principal, err := a.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !allowed(rule, principal, req) {
+		return nil, status.Errorf(codes.PermissionDenied, "access to %s denied", fullMethod)
+	}
+
+	return auth.WithPrincipal(ctx, principal), nil
+}
+
+// authenticate использует bearer токен, а при его отсутствии - сертификат mTLS
+// (кроме запросов через gateway, чье соединение несет сертификат самого gateway)
+func (a *Auth) authenticate(ctx context.Context) (*auth.Principal, error) {
+	token, err := auth.TokenFromContext(ctx)
+	if err == nil {
+		principal, err := a.authenticator.Authenticate(ctx, token)
+		if err != nil {
+			return nil, authenticationStatus(err)
+		}
+		return principal, nil
+	}
+
+	if identity := auth.PeerIdentity(ctx); identity != "" && !auth.ViaGateway(ctx) {
+		principal, err := a.authenticator.AuthenticatePeer(ctx, identity)
+		if err != nil {
+			return nil, authenticationStatus(err)
+		}
+		return principal, nil
+	}
+
+	return nil, status.Error(codes.Unauthenticated, err.Error())
+}
+
+func allowed(rule Rule, principal *auth.Principal, req interface{}) bool {
+	if principal.IsService() {
+		return rule.allowsService(principal.ServiceName)
+	}
+
+	switch rule.Policy {
+	case PolicyAuthenticated:
+		return true
+	case PolicySelfOrAdmin:
+		if principal.IsAdmin() {
+			return true
+		}
+		r, ok := req.(userIDRequest)
+		return ok && r.GetUserId() != "" && r.GetUserId() == principal.UserID
+	case PolicyAdminOnly:
+		return principal.IsAdmin()
+	default:
+		return false
+	}
+}
+
+func authenticationStatus(err error) error {
+	switch {
+	case errors.Is(err, service.ErrInvalidToken):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, service.ErrUserInactive):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	default:
+		return status.Error(codes.Internal, "failed to authenticate request")
+	}
+}
+
+func isReflectionMethod(fullMethod string) bool {
+	return strings.HasPrefix(fullMethod, "/grpc.reflection.")
+}
+
+// principalStream подменяет контекст стрима контекстом с вызывающим
+type principalStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *principalStream) Context() context.Context {
+	return s.ctx
+}
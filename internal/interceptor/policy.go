@@ -0,0 +1,148 @@
+package interceptor
+
+import (
+	"slices"
+
+	pb "github.com/haqury/user-service/pkg/gen"
+)
+
+// Policy - требование к пользователю, вызывающему RPC
+type Policy int
+
+const (
+	// PolicyAuthenticated - нужен действительный токен (политика по умолчанию)
+	PolicyAuthenticated Policy = iota
+	// PolicyPublic - токен не проверяется
+	PolicyPublic
+	// PolicySelfOrAdmin - вызывающий обращается к своим данным (user_id запроса) или он администратор
+	PolicySelfOrAdmin
+	// PolicyAdminOnly - только роль admin
+	PolicyAdminOnly
+	// PolicyServiceOnly - только сервисы из Rule.Services, пользователям доступ закрыт
+	PolicyServiceOnly
+)
+
+func (p Policy) String() string {
+	switch p {
+	case PolicyPublic:
+		return "public"
+	case PolicyAuthenticated:
+		return "authenticated"
+	case PolicySelfOrAdmin:
+		return "self-or-admin"
+	case PolicyAdminOnly:
+		return "admin-only"
+	case PolicyServiceOnly:
+		return "service-only"
+	default:
+		return "unknown"
+	}
+}
+
+// Имена сервисных аккаунтов внутренних сервисов
+const (
+	ServiceAPIGateway   = "api-gateway"
+	ServiceVideoService = "video-service"
+)
+
+// Rule - правило доступа к методу. Policy применяется к пользователям; сервисные
+// аккаунты допускаются, только если их имя есть в Services
+type Rule struct {
+	Policy   Policy
+	Services []string
+}
+
+// allowsService - сервисному аккаунту разрешен вызов метода
+func (r Rule) allowsService(name string) bool {
+	return slices.Contains(r.Services, name)
+}
+
+// Policies сопоставляет полное имя метода и правило доступа. Методы, которых нет
+// в таблице, доступны любому аутентифицированному пользователю
+type Policies map[string]Rule
+
+// UserServicePolicies - политики доступа к UserService
+var UserServicePolicies = Policies{
+	// Регистрация и получение токенов
+	pb.UserService_CreateUser_FullMethodName:        {Policy: PolicyPublic},
+	pb.UserService_Login_FullMethodName:             {Policy: PolicyPublic},
+	pb.UserService_RefreshToken_FullMethodName:      {Policy: PolicyPublic},
+	pb.UserService_ValidateToken_FullMethodName:     {Policy: PolicyPublic},
+	pb.UserService_IssueServiceToken_FullMethodName: {Policy: PolicyPublic},
+
+	pb.UserService_Logout_FullMethodName: {Policy: PolicyAuthenticated},
+
+	pb.UserService_GetUser_FullMethodName:               {Policy: PolicySelfOrAdmin},
+	pb.UserService_UpdateUser_FullMethodName:            {Policy: PolicySelfOrAdmin},
+	pb.UserService_UpdateStreamingConfig_FullMethodName: {Policy: PolicySelfOrAdmin},
+	pb.UserService_GetUserStats_FullMethodName:          {Policy: PolicySelfOrAdmin},
+	pb.UserService_ListSessions_FullMethodName:          {Policy: PolicySelfOrAdmin},
+	pb.UserService_RevokeSession_FullMethodName:         {Policy: PolicySelfOrAdmin},
+	pb.UserService_RevokeAllSessions_FullMethodName:     {Policy: PolicySelfOrAdmin},
+	pb.UserService_CreateAPIKey_FullMethodName:          {Policy: PolicySelfOrAdmin},
+	pb.UserService_ListAPIKeys_FullMethodName:           {Policy: PolicySelfOrAdmin},
+	pb.UserService_RevokeAPIKey_FullMethodName:          {Policy: PolicySelfOrAdmin},
+
+	// Вызываются внутренними сервисами
+	pb.UserService_GetStreamingConfig_FullMethodName: {
+		Policy:   PolicySelfOrAdmin,
+		Services: []string{ServiceAPIGateway, ServiceVideoService},
+	},
+	pb.UserService_HeartbeatStreamingSession_FullMethodName: {
+		Policy:   PolicySelfOrAdmin,
+		Services: []string{ServiceAPIGateway, ServiceVideoService},
+	},
+	pb.UserService_ReleaseStreamingSession_FullMethodName: {
+		Policy:   PolicySelfOrAdmin,
+		Services: []string{ServiceAPIGateway, ServiceVideoService},
+	},
+	pb.UserService_GetUserByClientId_FullMethodName: {
+		Policy:   PolicyAdminOnly,
+		Services: []string{ServiceAPIGateway},
+	},
+	pb.UserService_CheckPermission_FullMethodName: {
+		Policy:   PolicySelfOrAdmin,
+		Services: []string{ServiceAPIGateway},
+	},
+	pb.UserService_VerifyAPIKey_FullMethodName: {
+		Policy:   PolicyServiceOnly,
+		Services: []string{ServiceVideoService},
+	},
+	pb.UserService_ReportInstanceLoad_FullMethodName: {
+		Policy:   PolicyServiceOnly,
+		Services: []string{ServiceVideoService},
+	},
+	pb.UserService_UpdateUserStats_FullMethodName: {
+		Policy:   PolicyAdminOnly,
+		Services: []string{ServiceVideoService},
+	},
+
+	pb.UserService_GetUserByUsername_FullMethodName: {Policy: PolicyAdminOnly},
+	pb.UserService_DeleteUser_FullMethodName:        {Policy: PolicyAdminOnly},
+	pb.UserService_ListUsers_FullMethodName:         {Policy: PolicyAdminOnly},
+
+	pb.UserService_DrainInstance_FullMethodName:    {Policy: PolicyAdminOnly},
+	pb.UserService_SetInstanceState_FullMethodName: {Policy: PolicyAdminOnly},
+
+	pb.UserService_CreateRole_FullMethodName:       {Policy: PolicyAdminOnly},
+	pb.UserService_ListRoles_FullMethodName:        {Policy: PolicyAdminOnly},
+	pb.UserService_GrantPermission_FullMethodName:  {Policy: PolicyAdminOnly},
+	pb.UserService_RevokePermission_FullMethodName: {Policy: PolicyAdminOnly},
+	pb.UserService_AssignRole_FullMethodName:       {Policy: PolicyAdminOnly},
+	pb.UserService_RemoveRole_FullMethodName:       {Policy: PolicyAdminOnly},
+
+	pb.UserService_CreateServiceAccount_FullMethodName: {Policy: PolicyAdminOnly},
+	pb.UserService_ListServiceAccounts_FullMethodName:  {Policy: PolicyAdminOnly},
+	pb.UserService_DeleteServiceAccount_FullMethodName: {Policy: PolicyAdminOnly},
+}
+
+// rule возвращает правило метода. Reflection открыт для grpcurl и подобных инструментов
+func (p Policies) rule(fullMethod string) Rule {
+	if rule, ok := p[fullMethod]; ok {
+		return rule
+	}
+	if isReflectionMethod(fullMethod) {
+		return Rule{Policy: PolicyPublic}
+	}
+	return Rule{Policy: PolicyAuthenticated}
+}
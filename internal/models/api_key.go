@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Области действия API ключей
+const (
+	// APIKeyScopeStreamPublish - публикация потока в video-service
+	APIKeyScopeStreamPublish = "stream:publish"
+	// APIKeyScopeStreamView - просмотр потоков пользователя
+	APIKeyScopeStreamView = "stream:view"
+)
+
+// APIKeyScopes - все допустимые области действия
+var APIKeyScopes = []string{APIKeyScopeStreamPublish, APIKeyScopeStreamView}
+
+// APIKey - API ключ пользователя (запись auth_tokens с token_type = api_key).
+// Сам ключ выдается один раз при создании, хранится его хэш; Prefix - видимое
+// начало ключа для опознания в списке. ClientID заполнен у ключей, выданных
+// клиенту стриминга вместе с конфигурацией
+type APIKey struct {
+	ID         string         `db:"id" json:"id"`
+	UserID     string         `db:"user_id" json:"user_id"`
+	Name       string         `db:"name" json:"name"`
+	Prefix     string         `db:"key_prefix" json:"prefix"`
+	KeyHash    string         `db:"token" json:"-"`
+	Scopes     pq.StringArray `db:"scopes" json:"scopes"`
+	ClientID   string         `db:"-" json:"client_id,omitempty"`
+	IsActive   bool           `db:"is_active" json:"is_active"`
+	ExpiresAt  time.Time      `db:"expires_at" json:"expires_at"`
+	CreatedAt  time.Time      `db:"created_at" json:"created_at"`
+	LastUsedAt *time.Time     `db:"last_used_at" json:"last_used_at,omitempty"`
+}
+
+// HasScope - ключ допускает область действия scope
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
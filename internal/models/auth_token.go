@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+)
+
+// Типы токенов (ограничение CHECK на auth_tokens.token_type)
+const (
+	TokenTypeBearer = "bearer"
+	TokenTypeJWT    = "jwt"
+	TokenTypeAPIKey = "api_key"
+)
+
+// AuthToken - запись auth_tokens. Token и RefreshToken содержат хэши, а не сами токены.
+// FamilyID объединяет все пары токенов, полученные ротацией от одного Login
+type AuthToken struct {
+	ID               string     `db:"id" json:"id"`
+	UserID           string     `db:"user_id" json:"user_id"`
+	FamilyID         string     `db:"family_id" json:"family_id"`
+	TokenHash        string     `db:"token" json:"-"`
+	TokenType        string     `db:"token_type" json:"token_type"`
+	ExpiresAt        time.Time  `db:"expires_at" json:"expires_at"`
+	RefreshTokenHash *string    `db:"refresh_token" json:"-"`
+	RefreshExpiresAt *time.Time `db:"refresh_expires_at" json:"refresh_expires_at,omitempty"`
+	RefreshUsedAt    *time.Time `db:"refresh_used_at" json:"refresh_used_at,omitempty"`
+	ClientInfo       JSONB      `db:"client_info" json:"client_info"`
+	UserAgent        string     `db:"user_agent" json:"user_agent"`
+	IPAddress        string     `db:"ip_address" json:"ip_address"`
+	IsActive         bool       `db:"is_active" json:"is_active"`
+	CreatedAt        time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt        time.Time  `db:"updated_at" json:"updated_at"`
+	LastUsedAt       *time.Time `db:"last_used_at" json:"last_used_at,omitempty"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import (
+	"time"
+)
+
+// Role - роль RBAC. Пользователю роли назначаются по имени (users.roles)
+type Role struct {
+	Name        string    `db:"name" json:"name"`
+	Description string    `db:"description" json:"description"`
+	Permissions []string  `db:"-" json:"permissions"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+)
+
+// ServiceAccount - учетная запись внутреннего сервиса (api-gateway, video-service).
+// ClientSecretHash содержит SHA-256 секрета, сам секрет выдается один раз при создании
+type ServiceAccount struct {
+	ID               string     `db:"id" json:"id"`
+	Name             string     `db:"name" json:"name"`
+	Description      string     `db:"description" json:"description"`
+	ClientID         string     `db:"client_id" json:"client_id"`
+	ClientSecretHash string     `db:"client_secret_hash" json:"-"`
+	TLSIdentity      string     `db:"tls_identity" json:"tls_identity,omitempty"`
+	IsActive         bool       `db:"is_active" json:"is_active"`
+	CreatedAt        time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt        time.Time  `db:"updated_at" json:"updated_at"`
+	LastUsedAt       *time.Time `db:"last_used_at" json:"last_used_at,omitempty"`
+}
+
+// ServiceToken - выданный сервисному аккаунту токен доступа (хранится хэш)
+type ServiceToken struct {
+	ID               string    `db:"id" json:"id"`
+	ServiceAccountID string    `db:"service_account_id" json:"service_account_id"`
+	TokenHash        string    `db:"token" json:"-"`
+	ExpiresAt        time.Time `db:"expires_at" json:"expires_at"`
+	CreatedAt        time.Time `db:"created_at" json:"created_at"`
+}
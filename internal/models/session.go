@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+)
+
+// Session - активный вход пользователя. Сессия соответствует семейству токенов auth_tokens:
+// ID = family_id, данные клиента берутся из текущей (последней ротированной) пары
+type Session struct {
+	ID         string    `json:"id"`
+	UserID     string    `json:"user_id"`
+	TokenType  string    `json:"token_type"`
+	ClientInfo JSONB     `json:"client_info"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
@@ -33,11 +33,17 @@ type User struct {
 type JSONB map[string]interface{}
 
 // Value implements the driver.Valuer interface
+// Возвращаем строку, а не []byte: драйвер pg кодирует []byte как bytea,
+// что несовместимо с колонками типа JSONB
 func (j JSONB) Value() (driver.Value, error) {
 	if j == nil {
 		return nil, nil
 	}
-	return json.Marshal(j)
+	data, err := json.Marshal(j)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
 }
 
 // Scan implements the sql.Scanner interface
@@ -47,8 +53,13 @@ func (j *JSONB) Scan(value interface{}) error {
 		return nil
 	}
 
-	bytes, ok := value.([]byte)
-	if !ok {
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
 		return nil
 	}
 
@@ -61,6 +72,29 @@ func (j *JSONB) Scan(value interface{}) error {
 	return nil
 }
 
+// Decode раскладывает JSONB в типизированную структуру (например, UserSettings)
+func (j JSONB) Decode(dst interface{}) error {
+	data, err := json.Marshal(j)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dst)
+}
+
+// ToJSONB преобразует типизированную структуру в JSONB
+func ToJSONB(src interface{}) (JSONB, error) {
+	data, err := json.Marshal(src)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(JSONB)
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
 type UserSettings struct {
 	DefaultQuality       string `json:"default_quality"`
 	MaxParallelStreams   int32  `json:"max_parallel_streams"`
@@ -79,6 +113,16 @@ type StreamingConfig struct {
 	MaxBitrate     int32  `json:"max_bitrate"`
 	MaxResolution  int32  `json:"max_resolution"`
 	Codec          string `json:"codec"`
+
+	// StreamTicket - подписанный билет на подключение к назначенному инстансу (pkg/streamticket)
+	StreamTicket    string `json:"stream_ticket,omitempty"`
+	TicketExpiresAt int64  `json:"ticket_expires_at,omitempty"`
+
+	// Region - регион назначенного инстанса; FallbackDepth - его позиция в цепочке регионов
+	// пользователя (0 - свой регион). Инстанс вне цепочки не выдается: такое назначение
+	// снимается и клиент перенаправляется, поэтому значение всегда неотрицательно
+	Region        string `json:"region,omitempty"`
+	FallbackDepth int32  `json:"fallback_depth,omitempty"`
 }
 
 type UserStats struct {
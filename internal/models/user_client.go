@@ -2,16 +2,40 @@ package models
 
 import (
 	"time"
+
+	"github.com/lib/pq"
+)
+
+// Причины снятия назначения инстанса с клиента (user_clients.release_reason)
+const (
+	ReleaseReasonClient       = "released"     // клиент или video-service завершил сессию
+	ReleaseReasonStale        = "stale"        // клиент перестал присылать heartbeat
+	ReleaseReasonFailover     = "failover"     // назначенный инстанс стал недоступен
+	ReleaseReasonDrained      = "drained"      // инстанс выведен из роутинга (maintenance, retired, истек срок draining)
+	ReleaseReasonIncompatible = "incompatible" // инстанс не поддерживает кодеки клиента
+	ReleaseReasonRegion       = "region"       // регион инстанса вне цепочки регионов пользователя
 )
 
 type UserClient struct {
-	ID                 string    `db:"id" json:"id"`
-	UserID             string    `db:"user_id" json:"user_id"`
-	ClientID           string    `db:"client_id" json:"client_id"`
-	ClientInfo         JSONB     `db:"client_info" json:"client_info"`
-	AssignedInstanceID *string   `db:"assigned_instance_id" json:"assigned_instance_id,omitempty"`
-	IsActive           bool      `db:"is_active" json:"is_active"`
-	CreatedAt          time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt          time.Time `db:"updated_at" json:"updated_at"`
-	LastSeen           time.Time `db:"last_seen" json:"last_seen"`
+	ID                 string  `db:"id" json:"id"`
+	UserID             string  `db:"user_id" json:"user_id"`
+	ClientID           string  `db:"client_id" json:"client_id"`
+	ClientInfo         JSONB   `db:"client_info" json:"client_info"`
+	AssignedInstanceID *string `db:"assigned_instance_id" json:"assigned_instance_id,omitempty"`
+	// Codecs - кодеки, сообщенные клиентом при назначении инстанса; пусто - любой
+	Codecs        pq.StringArray `db:"codecs" json:"codecs,omitempty"`
+	IsActive      bool           `db:"is_active" json:"is_active"`
+	CreatedAt     time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt     time.Time      `db:"updated_at" json:"updated_at"`
+	LastSeen      time.Time      `db:"last_seen" json:"last_seen"`
+	ReleaseReason *string        `db:"release_reason" json:"release_reason,omitempty"`
+	ReleasedAt    *time.Time     `db:"released_at" json:"released_at,omitempty"`
+}
+
+// ClientCapabilities - возможности клиента, с которыми согласуются параметры потока;
+// нулевые значения - без ограничения
+type ClientCapabilities struct {
+	Codecs        []string // поддерживаемые кодеки; пусто - любой
+	MaxResolution int32    // максимальная высота кадра
+	BandwidthKbps int32    // оценка пропускной способности, кбит/с
 }
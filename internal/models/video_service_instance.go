@@ -6,17 +6,57 @@ import (
 	"github.com/lib/pq"
 )
 
+// Состояния здоровья инстанса (ограничение CHECK на video_service_instances.health_status).
+// Роутинг направляет клиентов только на healthy инстансы
+const (
+	HealthStatusHealthy   = "healthy"
+	HealthStatusDegraded  = "degraded"
+	HealthStatusUnhealthy = "unhealthy"
+)
+
+// Состояния жизненного цикла инстанса (video_service_instances.state)
+const (
+	InstanceStateActive      = "active"      // принимает новых клиентов
+	InstanceStateDraining    = "draining"    // новых не принимает, назначенные остаются до DrainDeadline
+	InstanceStateMaintenance = "maintenance" // выведен из роутинга, назначенные перенаправляются
+	InstanceStateRetired     = "retired"     // выведен навсегда
+)
+
+// InstanceStateTransitions - допустимые переходы: из состояния-ключа в перечисленные.
+// Переход draining -> draining переносит срок вывода
+var InstanceStateTransitions = map[string][]string{
+	InstanceStateActive:      {InstanceStateDraining, InstanceStateMaintenance},
+	InstanceStateDraining:    {InstanceStateActive, InstanceStateDraining, InstanceStateMaintenance, InstanceStateRetired},
+	InstanceStateMaintenance: {InstanceStateActive, InstanceStateRetired},
+	InstanceStateRetired:     {},
+}
+
+// InstanceStateSources возвращает состояния, из которых разрешен переход в state
+func InstanceStateSources(state string) []string {
+	var sources []string
+	for from, targets := range InstanceStateTransitions {
+		for _, to := range targets {
+			if to == state {
+				sources = append(sources, from)
+			}
+		}
+	}
+	return sources
+}
+
 type VideoServiceInstance struct {
-	ID              string         `db:"id" json:"id"`
-	Name            string         `db:"name" json:"name"`
-	ServerURL       string         `db:"server_url" json:"server_url"`
-	ServerPort      int32          `db:"server_port" json:"server_port"`
-	UseSSL          bool           `db:"use_ssl" json:"use_ssl"`
-	StreamEndpoint  string         `db:"stream_endpoint" json:"stream_endpoint"`
-	Region          string         `db:"region" json:"region"`
-	Priority        int32          `db:"priority" json:"priority"`
-	MaxCapacity     int32          `db:"max_capacity" json:"max_capacity"`
-	CurrentLoad     int32          `db:"current_load" json:"current_load"`
+	ID             string `db:"id" json:"id"`
+	Name           string `db:"name" json:"name"`
+	ServerURL      string `db:"server_url" json:"server_url"`
+	ServerPort     int32  `db:"server_port" json:"server_port"`
+	UseSSL         bool   `db:"use_ssl" json:"use_ssl"`
+	StreamEndpoint string `db:"stream_endpoint" json:"stream_endpoint"`
+	Region         string `db:"region" json:"region"`
+	Priority       int32  `db:"priority" json:"priority"`
+	MaxCapacity    int32  `db:"max_capacity" json:"max_capacity"`
+	CurrentLoad    int32  `db:"current_load" json:"current_load"`
+	// ReportedLoad - нагрузка из последнего heartbeat инстанса; nil - heartbeat еще не было
+	ReportedLoad    *int32         `db:"reported_load" json:"reported_load,omitempty"`
 	HealthStatus    string         `db:"health_status" json:"health_status"`
 	AllowedTiers    pq.StringArray `db:"allowed_tiers" json:"allowed_tiers"`
 	MaxBitrate      int32          `db:"max_bitrate" json:"max_bitrate"`
@@ -27,4 +67,21 @@ type VideoServiceInstance struct {
 	CreatedAt       time.Time      `db:"created_at" json:"created_at"`
 	UpdatedAt       time.Time      `db:"updated_at" json:"updated_at"`
 	LastHealthCheck *time.Time     `db:"last_health_check" json:"last_health_check,omitempty"`
+	LastHeartbeat   *time.Time     `db:"last_heartbeat" json:"last_heartbeat,omitempty"`
+	State           string         `db:"state" json:"state"`
+	StateChangedAt  *time.Time     `db:"state_changed_at" json:"state_changed_at,omitempty"`
+	DrainDeadline   *time.Time     `db:"drain_deadline" json:"drain_deadline,omitempty"`
+}
+
+// KeepsClients сообщает, могут ли назначенные клиенты оставаться на инстансе в момент now:
+// инстанс активен или выводится и срок вывода не истек
+func (i *VideoServiceInstance) KeepsClients(now time.Time) bool {
+	switch i.State {
+	case InstanceStateActive:
+		return true
+	case InstanceStateDraining:
+		return i.DrainDeadline == nil || now.Before(*i.DrainDeadline)
+	default:
+		return false
+	}
 }
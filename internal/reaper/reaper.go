@@ -0,0 +1,103 @@
+// Package reaper снимает назначения инстансов video-service с клиентов, которые перестали
+// присылать heartbeat сессии стриминга или остались на выведенных из роутинга инстансах,
+// и освобождает занятые ими места
+package reaper
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/haqury/user-service/internal/config"
+	"github.com/haqury/user-service/internal/models"
+	"github.com/haqury/user-service/internal/repository"
+)
+
+// Reaper периодически освобождает назначения отключившихся клиентов. Выборка идет с
+// SKIP LOCKED, поэтому несколько реплик user-service могут работать одновременно
+type Reaper struct {
+	repo   repository.UserClientRepository
+	config config.SessionConfig
+}
+
+// NewReaper проверяет конфигурацию; ошибка в ней - ошибка старта
+func NewReaper(repo repository.UserClientRepository, cfg config.SessionConfig) (*Reaper, error) {
+	if cfg.ClientTTL <= 0 {
+		return nil, errors.New("client session ttl must be positive")
+	}
+	if cfg.ReapBatch <= 0 {
+		return nil, errors.New("session reap batch must be positive")
+	}
+
+	return &Reaper{repo: repo, config: cfg}, nil
+}
+
+// Run освобождает назначения каждые ReapInterval, пока не отменен ctx
+func (r *Reaper) Run(ctx context.Context) {
+	if r.config.ReapInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(r.config.ReapInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if _, err := r.ReapOnce(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Session reaper: %v", err)
+		}
+	}
+}
+
+// ReapOnce освобождает назначения клиентов без heartbeat дольше ClientTTL и клиентов
+// инстансов, выведенных из роутинга, и возвращает их число
+func (r *Reaper) ReapOnce(ctx context.Context) (int, error) {
+	seenBefore := time.Now().Add(-r.config.ClientTTL)
+
+	stale, err := r.reap(ctx, func(limit int) ([]*models.UserClient, error) {
+		return r.repo.ReleaseStale(ctx, seenBefore, limit, models.ReleaseReasonStale)
+	})
+	if err != nil {
+		return stale, err
+	}
+
+	evicted, err := r.reap(ctx, func(limit int) ([]*models.UserClient, error) {
+		return r.repo.ReleaseEvicted(ctx, limit, models.ReleaseReasonDrained)
+	})
+	return stale + evicted, err
+}
+
+// reap вызывает release пачками по ReapBatch, пока пачка не окажется неполной
+func (r *Reaper) reap(ctx context.Context, release func(limit int) ([]*models.UserClient, error)) (int, error) {
+	total := 0
+	for {
+		released, err := release(r.config.ReapBatch)
+		if err != nil {
+			return total, err
+		}
+
+		for _, client := range released {
+			log.Printf("Session reaper: released instance %s of client %s (user %s): %s, last seen %s",
+				valueOf(client.AssignedInstanceID), client.ClientID, client.UserID,
+				valueOf(client.ReleaseReason), client.LastSeen.Format(time.RFC3339))
+		}
+
+		total += len(released)
+		if len(released) < r.config.ReapBatch || ctx.Err() != nil {
+			return total, nil
+		}
+	}
+}
+
+func valueOf(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/haqury/user-service/internal/models"
+)
+
+// APIKeyRepository хранит API ключи в auth_tokens (token_type = api_key).
+// Методы принимают хэш ключа, сам ключ в БД не попадает
+type APIKeyRepository interface {
+	// Create сохраняет ключ. Ключ клиента стриминга (ClientID не пуст) заменяет
+	// предыдущие активные ключи этого клиента в одной транзакции
+	Create(ctx context.Context, key *models.APIKey) error
+	// ListByUser возвращает активные ключи пользователя, новые первыми
+	ListByUser(ctx context.Context, userID string) ([]*models.APIKey, error)
+	// Revoke деактивирует ключ пользователя
+	Revoke(ctx context.Context, userID, keyID string) error
+	// Use возвращает активный неистекший ключ по хэшу, отмечая время его использования
+	Use(ctx context.Context, keyHash string) (*models.APIKey, error)
+}
+
+type apiKeyRepository struct {
+	db *sql.DB
+}
+
+func NewAPIKeyRepository(db *sql.DB) APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+const apiKeyColumns = `
+	id, user_id, COALESCE(name, ''), COALESCE(key_prefix, ''), token,
+	COALESCE(scopes, '{}'), COALESCE(client_info->>'client_id', ''),
+	COALESCE(is_active, true), expires_at, created_at, last_used_at
+`
+
+// apiKeyCondition отделяет API ключи от токенов сессий в общей таблице
+const apiKeyCondition = `token_type = '` + models.TokenTypeAPIKey + `'`
+
+func scanAPIKey(row rowScanner) (*models.APIKey, error) {
+	var k models.APIKey
+	err := row.Scan(
+		&k.ID, &k.UserID, &k.Name, &k.Prefix, &k.KeyHash,
+		&k.Scopes, &k.ClientID,
+		&k.IsActive, &k.ExpiresAt, &k.CreatedAt, &k.LastUsedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
+
+func (r *apiKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if key.ClientID != "" {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE auth_tokens SET is_active = false
+			WHERE user_id = $1 AND client_info->>'client_id' = $2
+			  AND is_active = true AND `+apiKeyCondition,
+			key.UserID, key.ClientID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to revoke previous client api keys: %w", err)
+		}
+	}
+
+	clientInfo := models.JSONB{}
+	if key.ClientID != "" {
+		clientInfo["client_id"] = key.ClientID
+	}
+
+	query := `
+		INSERT INTO auth_tokens (
+			user_id, family_id, token, token_type, expires_at, name, key_prefix, scopes, client_info
+		)
+		VALUES ($1, gen_random_uuid(), $2, '` + models.TokenTypeAPIKey + `', $3, $4, $5, $6::TEXT[], $7::JSONB)
+		RETURNING ` + apiKeyColumns
+
+	created, err := scanAPIKey(tx.QueryRowContext(
+		ctx, query,
+		key.UserID, key.KeyHash, key.ExpiresAt, key.Name, key.Prefix, key.Scopes, clientInfo,
+	))
+	if isForeignKeyViolation(err) {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create api key: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit api key: %w", err)
+	}
+
+	*key = *created
+	return nil
+}
+
+func (r *apiKeyRepository) ListByUser(ctx context.Context, userID string) ([]*models.APIKey, error) {
+	query := `
+		SELECT ` + apiKeyColumns + ` FROM auth_tokens
+		WHERE user_id = $1 AND is_active = true AND expires_at > CURRENT_TIMESTAMP AND ` + apiKeyCondition + `
+		ORDER BY created_at DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := make([]*models.APIKey, 0)
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan api key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate api keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+func (r *apiKeyRepository) Revoke(ctx context.Context, userID, keyID string) error {
+	query := `
+		UPDATE auth_tokens SET is_active = false
+		WHERE user_id = $1 AND id = $2 AND is_active = true AND ` + apiKeyCondition
+
+	result, err := r.db.ExecContext(ctx, query, userID, keyID)
+	if isInvalidTextRepresentation(err) {
+		return ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+
+	return requireAffected(result, ErrAPIKeyNotFound)
+}
+
+func (r *apiKeyRepository) Use(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	query := `
+		UPDATE auth_tokens SET last_used_at = CURRENT_TIMESTAMP
+		WHERE token = $1 AND is_active = true AND expires_at > CURRENT_TIMESTAMP AND ` + apiKeyCondition + `
+		RETURNING ` + apiKeyColumns
+
+	key, err := scanAPIKey(r.db.QueryRowContext(ctx, query, keyHash))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to use api key: %w", err)
+	}
+
+	return key, nil
+}
@@ -2,28 +2,252 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/haqury/user-service/internal/models"
 )
 
+// AuthRepository хранит токены в auth_tokens. Все методы принимают хэш токена,
+// сам токен в БД не попадает
 type AuthRepository interface {
-	CreateToken(ctx context.Context, userID string) (string, error)
-	ValidateToken(ctx context.Context, token string) (string, error)
-	RevokeToken(ctx context.Context, token string) error
+	CreateToken(ctx context.Context, token *models.AuthToken) error
+	ValidateToken(ctx context.Context, tokenHash string) (*models.AuthToken, error)
+	RevokeToken(ctx context.Context, tokenHash string) (*models.AuthToken, error)
+
+	// GetByRefreshToken ищет запись по хэшу refresh токена независимо от ее состояния
+	GetByRefreshToken(ctx context.Context, refreshHash string) (*models.AuthToken, error)
+	// ConsumeRefreshToken атомарно помечает refresh токен использованным и деактивирует пару
+	ConsumeRefreshToken(ctx context.Context, refreshHash string) (*models.AuthToken, error)
+	// RevokeFamily отзывает все токены семейства
+	RevokeFamily(ctx context.Context, familyID string) error
+
+	// ListSessions возвращает активные сессии пользователя, последние использованные первыми
+	ListSessions(ctx context.Context, userID string) ([]*models.Session, error)
+	// RevokeSession отзывает сессию (семейство токенов) пользователя
+	RevokeSession(ctx context.Context, userID, sessionID string) error
+	// RevokeUserSessions отзывает все сессии пользователя и возвращает их количество
+	RevokeUserSessions(ctx context.Context, userID string) (int, error)
+}
+
+type authRepository struct {
+	db *sql.DB
+}
+
+func NewAuthRepository(db *sql.DB) AuthRepository {
+	return &authRepository{db: db}
+}
+
+const authTokenColumns = `
+	id, user_id, family_id, token, COALESCE(token_type, 'bearer'), expires_at,
+	refresh_token, refresh_expires_at, refresh_used_at, client_info,
+	COALESCE(user_agent, ''), COALESCE(host(ip_address), ''),
+	COALESCE(is_active, true), created_at, updated_at, last_used_at
+`
+
+func scanAuthToken(row rowScanner) (*models.AuthToken, error) {
+	var t models.AuthToken
+	err := row.Scan(
+		&t.ID, &t.UserID, &t.FamilyID, &t.TokenHash, &t.TokenType, &t.ExpiresAt,
+		&t.RefreshTokenHash, &t.RefreshExpiresAt, &t.RefreshUsedAt, &t.ClientInfo,
+		&t.UserAgent, &t.IPAddress,
+		&t.IsActive, &t.CreatedAt, &t.UpdatedAt, &t.LastUsedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
 }
 
-type authRepository struct{}
+// CreateToken сохраняет токен. Пустой FamilyID открывает новое семейство
+func (r *authRepository) CreateToken(ctx context.Context, token *models.AuthToken) error {
+	query := `
+		INSERT INTO auth_tokens (
+			user_id, family_id, token, token_type, expires_at, refresh_token, refresh_expires_at,
+			client_info, user_agent, ip_address
+		)
+		VALUES ($1, COALESCE($2::UUID, gen_random_uuid()), $3, $4, $5, $6, $7, COALESCE($8::JSONB, '{}'), $9, $10::INET)
+		RETURNING ` + authTokenColumns
+
+	created, err := scanAuthToken(r.db.QueryRowContext(
+		ctx, query,
+		token.UserID, nullString(token.FamilyID), token.TokenHash, token.TokenType, token.ExpiresAt,
+		token.RefreshTokenHash, token.RefreshExpiresAt,
+		token.ClientInfo, nullString(token.UserAgent), nullString(token.IPAddress),
+	))
+	if err != nil {
+		return fmt.Errorf("failed to create token: %w", err)
+	}
+
+	*token = *created
+	return nil
+}
+
+// ValidateToken возвращает активный и не истекший токен сессии, отмечая время его использования.
+// API ключи (APIKeyRepository) токеном доступа к RPC не являются
+func (r *authRepository) ValidateToken(ctx context.Context, tokenHash string) (*models.AuthToken, error) {
+	query := `
+		UPDATE auth_tokens SET last_used_at = CURRENT_TIMESTAMP
+		WHERE token = $1 AND is_active = true AND expires_at > CURRENT_TIMESTAMP
+		  AND COALESCE(token_type, 'bearer') IN ` + sessionTokenTypes + `
+		RETURNING ` + authTokenColumns
+
+	token, err := scanAuthToken(r.db.QueryRowContext(ctx, query, tokenHash))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTokenNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate token: %w", err)
+	}
+
+	return token, nil
+}
+
+// RevokeToken деактивирует токен (is_active = false) вместе с его refresh токеном
+// и возвращает отозванную запись
+func (r *authRepository) RevokeToken(ctx context.Context, tokenHash string) (*models.AuthToken, error) {
+	query := `
+		UPDATE auth_tokens SET is_active = false
+		WHERE token = $1 AND is_active = true
+		  AND COALESCE(token_type, 'bearer') IN ` + sessionTokenTypes + `
+		RETURNING ` + authTokenColumns
+
+	token, err := scanAuthToken(r.db.QueryRowContext(ctx, query, tokenHash))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTokenNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke token: %w", err)
+	}
 
-func NewAuthRepository() AuthRepository {
-	return &authRepository{}
+	return token, nil
 }
 
-func (r *authRepository) CreateToken(ctx context.Context, userID string) (string, error) {
-	return "dummy-token-" + userID, nil
+func (r *authRepository) GetByRefreshToken(ctx context.Context, refreshHash string) (*models.AuthToken, error) {
+	query := `SELECT ` + authTokenColumns + ` FROM auth_tokens WHERE refresh_token = $1`
+
+	token, err := scanAuthToken(r.db.QueryRowContext(ctx, query, refreshHash))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTokenNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get token by refresh token: %w", err)
+	}
+
+	return token, nil
 }
 
-func (r *authRepository) ValidateToken(ctx context.Context, token string) (string, error) {
-	return "user-id-from-token", nil
+// ConsumeRefreshToken срабатывает только для активного, неиспользованного и не истекшего
+// refresh токена. Условный UPDATE гарантирует, что из двух параллельных запросов
+// с одним refresh токеном успешен будет только один
+func (r *authRepository) ConsumeRefreshToken(ctx context.Context, refreshHash string) (*models.AuthToken, error) {
+	query := `
+		UPDATE auth_tokens SET refresh_used_at = CURRENT_TIMESTAMP, is_active = false
+		WHERE refresh_token = $1
+		  AND refresh_used_at IS NULL
+		  AND is_active = true
+		  AND refresh_expires_at > CURRENT_TIMESTAMP
+		RETURNING ` + authTokenColumns
+
+	token, err := scanAuthToken(r.db.QueryRowContext(ctx, query, refreshHash))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTokenNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume refresh token: %w", err)
+	}
+
+	return token, nil
 }
 
-func (r *authRepository) RevokeToken(ctx context.Context, token string) error {
+func (r *authRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	query := `UPDATE auth_tokens SET is_active = false WHERE family_id = $1 AND is_active = true`
+
+	if _, err := r.db.ExecContext(ctx, query, familyID); err != nil {
+		return fmt.Errorf("failed to revoke token family: %w", err)
+	}
 	return nil
 }
+
+// sessionTokenTypes - типы токенов, выдаваемые при входе (API ключи сессиями не считаются)
+const sessionTokenTypes = `('` + models.TokenTypeBearer + `', '` + models.TokenTypeJWT + `')`
+
+// Сессия жива, пока действует access или refresh токен текущей пары
+const activeSessionCondition = `
+	t.is_active = true
+	AND COALESCE(t.token_type, 'bearer') IN ` + sessionTokenTypes + `
+	AND (t.expires_at > CURRENT_TIMESTAMP OR t.refresh_expires_at > CURRENT_TIMESTAMP)
+`
+
+func (r *authRepository) ListSessions(ctx context.Context, userID string) ([]*models.Session, error) {
+	query := `
+		SELECT
+			t.family_id, t.user_id, COALESCE(t.token_type, 'bearer'), t.client_info,
+			COALESCE(t.user_agent, ''), COALESCE(host(t.ip_address), ''),
+			(SELECT MIN(f.created_at) FROM auth_tokens f WHERE f.family_id = t.family_id),
+			COALESCE(t.last_used_at, t.created_at),
+			GREATEST(t.expires_at, COALESCE(t.refresh_expires_at, t.expires_at))
+		FROM auth_tokens t
+		WHERE t.user_id = $1 AND ` + activeSessionCondition + `
+		ORDER BY COALESCE(t.last_used_at, t.created_at) DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := make([]*models.Session, 0)
+	for rows.Next() {
+		var s models.Session
+		err := rows.Scan(
+			&s.ID, &s.UserID, &s.TokenType, &s.ClientInfo,
+			&s.UserAgent, &s.IPAddress,
+			&s.CreatedAt, &s.LastUsedAt, &s.ExpiresAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessions = append(sessions, &s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate sessions: %w", err)
+	}
+
+	return sessions, nil
+}
+
+func (r *authRepository) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	query := `
+		UPDATE auth_tokens t SET is_active = false
+		WHERE t.user_id = $1 AND t.family_id = $2 AND ` + activeSessionCondition
+
+	result, err := r.db.ExecContext(ctx, query, userID, sessionID)
+	if isInvalidTextRepresentation(err) {
+		return ErrSessionNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	return requireAffected(result, ErrSessionNotFound)
+}
+
+func (r *authRepository) RevokeUserSessions(ctx context.Context, userID string) (int, error) {
+	query := `
+		UPDATE auth_tokens t SET is_active = false
+		WHERE t.user_id = $1 AND t.is_active = true
+		  AND COALESCE(t.token_type, 'bearer') IN ` + sessionTokenTypes
+
+	result, err := r.db.ExecContext(ctx, query, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke user sessions: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	return int(affected), nil
+}
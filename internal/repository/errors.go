@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/uptrace/bun/driver/pgdriver"
+)
+
+var (
+	// ErrNotFound - запись не найдена (проверяется через errors.Is)
+	ErrNotFound = errors.New("not found")
+
+	// ErrAlreadyExists - нарушено ограничение уникальности
+	ErrAlreadyExists = errors.New("already exists")
+
+	ErrUserNotFound      = fmt.Errorf("user %w", ErrNotFound)
+	ErrUserAlreadyExists = fmt.Errorf("user %w", ErrAlreadyExists)
+
+	ErrUserClientNotFound      = fmt.Errorf("user client %w", ErrNotFound)
+	ErrUserClientAlreadyExists = fmt.Errorf("user client %w", ErrAlreadyExists)
+	// ErrUserClientAssigned - клиенту уже назначен инстанс (параллельный запрос успел раньше)
+	ErrUserClientAssigned = errors.New("user client already has an assigned instance")
+	// ErrStreamingSessionNotFound - у клиента нет назначенного инстанса (сессия завершена
+	// или освобождена reaper'ом); клиенту нужно заново запросить конфигурацию стриминга
+	ErrStreamingSessionNotFound = fmt.Errorf("streaming session %w", ErrNotFound)
+
+	ErrVideoServiceInstanceNotFound      = fmt.Errorf("video service instance %w", ErrNotFound)
+	ErrVideoServiceInstanceAlreadyExists = fmt.Errorf("video service instance %w", ErrAlreadyExists)
+
+	// ErrInstanceFull - на инстансе нет свободного места (или он перестал быть доступным)
+	ErrInstanceFull = errors.New("video service instance has no free capacity")
+	// ErrInstanceStateTransition - переход из текущего состояния инстанса не разрешен
+	ErrInstanceStateTransition = errors.New("video service instance state transition is not allowed")
+
+	ErrTokenNotFound = fmt.Errorf("token %w", ErrNotFound)
+
+	ErrSessionNotFound = fmt.Errorf("session %w", ErrNotFound)
+
+	ErrAPIKeyNotFound = fmt.Errorf("api key %w", ErrNotFound)
+
+	ErrRoleNotFound           = fmt.Errorf("role %w", ErrNotFound)
+	ErrRoleAlreadyExists      = fmt.Errorf("role %w", ErrAlreadyExists)
+	ErrRolePermissionNotFound = fmt.Errorf("role permission %w", ErrNotFound)
+
+	ErrServiceAccountNotFound      = fmt.Errorf("service account %w", ErrNotFound)
+	ErrServiceAccountAlreadyExists = fmt.Errorf("service account %w", ErrAlreadyExists)
+)
+
+// isUniqueViolation проверяет, что ошибка PostgreSQL - нарушение уникальности (23505)
+func isUniqueViolation(err error) bool {
+	var pgErr pgdriver.Error
+	if errors.As(err, &pgErr) {
+		return pgErr.Field('C') == "23505"
+	}
+	return false
+}
+
+// isForeignKeyViolation - нарушение внешнего ключа (23503): ссылка на несуществующую запись
+func isForeignKeyViolation(err error) bool {
+	var pgErr pgdriver.Error
+	if errors.As(err, &pgErr) {
+		return pgErr.Field('C') == "23503"
+	}
+	return false
+}
+
+// isInvalidTextRepresentation - значение не приводится к типу колонки (22P02), например
+// строка, не являющаяся UUID
+func isInvalidTextRepresentation(err error) bool {
+	var pgErr pgdriver.Error
+	if errors.As(err, &pgErr) {
+		return pgErr.Field('C') == "22P02"
+	}
+	return false
+}
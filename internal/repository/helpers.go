@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// nullString превращает пустую строку в NULL
+func nullString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// nullInt32 превращает нулевое значение в NULL
+func nullInt32(v int32) interface{} {
+	if v == 0 {
+		return nil
+	}
+	return v
+}
+
+// nullStringArray превращает пустой массив в NULL
+func nullStringArray(v pq.StringArray) interface{} {
+	if len(v) == 0 {
+		return nil
+	}
+	return v
+}
+
+// placeholders возвращает "$from, $from+1, ..." для n параметров
+func placeholders(from, n int) string {
+	parts := make([]string, n)
+	for i := range parts {
+		parts[i] = fmt.Sprintf("$%d", from+i)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// requireAffected возвращает notFound, если запрос не затронул ни одной строки
+func requireAffected(result sql.Result, notFound error) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if affected == 0 {
+		return notFound
+	}
+	return nil
+}
@@ -7,20 +7,19 @@ type Repositories struct {
 	Auth                 AuthRepository
 	VideoServiceInstance VideoServiceInstanceRepository
 	UserClient           UserClientRepository
-}
-
-func New() *Repositories {
-	return &Repositories{
-		User: NewUserRepository(),
-		Auth: NewAuthRepository(),
-	}
+	Role                 RoleRepository
+	ServiceAccount       ServiceAccountRepository
+	APIKey               APIKeyRepository
 }
 
 func NewWithDB(db *sql.DB) *Repositories {
 	return &Repositories{
-		User:                 NewUserRepository(),
-		Auth:                 NewAuthRepository(),
+		User:                 NewUserRepository(db),
+		Auth:                 NewAuthRepository(db),
 		VideoServiceInstance: NewVideoServiceInstanceRepository(db),
 		UserClient:           NewUserClientRepository(db),
+		Role:                 NewRoleRepository(db),
+		ServiceAccount:       NewServiceAccountRepository(db),
+		APIKey:               NewAPIKeyRepository(db),
 	}
 }
@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"github.com/haqury/user-service/internal/models"
+)
+
+type RoleRepository interface {
+	// Create создает роль вместе с ее правами
+	Create(ctx context.Context, role *models.Role) error
+	GetByName(ctx context.Context, name string) (*models.Role, error)
+	List(ctx context.Context) ([]*models.Role, error)
+
+	GrantPermission(ctx context.Context, role, permission string) error
+	RevokePermission(ctx context.Context, role, permission string) error
+
+	// PermissionsByRoles возвращает права каждой из ролей (роли без прав в результат не попадают)
+	PermissionsByRoles(ctx context.Context, roles []string) (map[string][]string, error)
+}
+
+type roleRepository struct {
+	db *sql.DB
+}
+
+func NewRoleRepository(db *sql.DB) RoleRepository {
+	return &roleRepository{db: db}
+}
+
+const roleColumns = `name, COALESCE(description, ''), created_at, updated_at`
+
+func scanRole(row rowScanner) (*models.Role, error) {
+	var r models.Role
+	if err := row.Scan(&r.Name, &r.Description, &r.CreatedAt, &r.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func (r *roleRepository) Create(ctx context.Context, role *models.Role) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO roles (name, description) VALUES ($1, $2) RETURNING ` + roleColumns
+
+	created, err := scanRole(tx.QueryRowContext(ctx, query, role.Name, role.Description))
+	if isUniqueViolation(err) {
+		return ErrRoleAlreadyExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create role: %w", err)
+	}
+
+	for _, permission := range role.Permissions {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO role_permissions (role_name, permission) VALUES ($1, $2) ON CONFLICT DO NOTHING`,
+			role.Name, permission,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to grant permission: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit role: %w", err)
+	}
+
+	created.Permissions = role.Permissions
+	*role = *created
+	return nil
+}
+
+func (r *roleRepository) GetByName(ctx context.Context, name string) (*models.Role, error) {
+	query := `SELECT ` + roleColumns + ` FROM roles WHERE name = $1`
+
+	role, err := scanRole(r.db.QueryRowContext(ctx, query, name))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRoleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get role: %w", err)
+	}
+
+	permissions, err := r.PermissionsByRoles(ctx, []string{name})
+	if err != nil {
+		return nil, err
+	}
+	role.Permissions = permissions[name]
+
+	return role, nil
+}
+
+func (r *roleRepository) List(ctx context.Context) ([]*models.Role, error) {
+	query := `SELECT ` + roleColumns + ` FROM roles ORDER BY name`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list roles: %w", err)
+	}
+	defer rows.Close()
+
+	var (
+		roles []*models.Role
+		names []string
+	)
+	for rows.Next() {
+		role, err := scanRole(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan role: %w", err)
+		}
+		roles = append(roles, role)
+		names = append(names, role.Name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate roles: %w", err)
+	}
+
+	permissions, err := r.PermissionsByRoles(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+	for _, role := range roles {
+		role.Permissions = permissions[role.Name]
+	}
+
+	return roles, nil
+}
+
+func (r *roleRepository) GrantPermission(ctx context.Context, role, permission string) error {
+	query := `INSERT INTO role_permissions (role_name, permission) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+
+	_, err := r.db.ExecContext(ctx, query, role, permission)
+	if isForeignKeyViolation(err) {
+		return ErrRoleNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to grant permission: %w", err)
+	}
+	return nil
+}
+
+func (r *roleRepository) RevokePermission(ctx context.Context, role, permission string) error {
+	query := `DELETE FROM role_permissions WHERE role_name = $1 AND permission = $2`
+
+	result, err := r.db.ExecContext(ctx, query, role, permission)
+	if err != nil {
+		return fmt.Errorf("failed to revoke permission: %w", err)
+	}
+
+	return requireAffected(result, ErrRolePermissionNotFound)
+}
+
+func (r *roleRepository) PermissionsByRoles(ctx context.Context, roles []string) (map[string][]string, error) {
+	result := make(map[string][]string, len(roles))
+	if len(roles) == 0 {
+		return result, nil
+	}
+
+	query := `
+		SELECT role_name, permission FROM role_permissions
+		WHERE role_name = ANY($1::TEXT[])
+		ORDER BY role_name, permission`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.StringArray(roles))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get role permissions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var role, permission string
+		if err := rows.Scan(&role, &permission); err != nil {
+			return nil, fmt.Errorf("failed to scan role permission: %w", err)
+		}
+		result[role] = append(result[role], permission)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate role permissions: %w", err)
+	}
+
+	return result, nil
+}
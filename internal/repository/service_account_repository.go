@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/haqury/user-service/internal/models"
+)
+
+type ServiceAccountRepository interface {
+	Create(ctx context.Context, account *models.ServiceAccount) error
+	GetByClientID(ctx context.Context, clientID string) (*models.ServiceAccount, error)
+	GetByTLSIdentity(ctx context.Context, identity string) (*models.ServiceAccount, error)
+	List(ctx context.Context) ([]*models.ServiceAccount, error)
+	Delete(ctx context.Context, id string) error
+
+	// CreateToken сохраняет токен и отмечает время использования аккаунта
+	CreateToken(ctx context.Context, token *models.ServiceToken) error
+	// GetByToken возвращает активный аккаунт по хэшу неистекшего токена
+	GetByToken(ctx context.Context, tokenHash string) (*models.ServiceAccount, error)
+}
+
+type serviceAccountRepository struct {
+	db *sql.DB
+}
+
+func NewServiceAccountRepository(db *sql.DB) ServiceAccountRepository {
+	return &serviceAccountRepository{db: db}
+}
+
+const serviceAccountColumns = `
+	id, name, COALESCE(description, ''), client_id, client_secret_hash,
+	COALESCE(tls_identity, ''), COALESCE(is_active, true),
+	created_at, updated_at, last_used_at
+`
+
+func scanServiceAccount(row rowScanner) (*models.ServiceAccount, error) {
+	var a models.ServiceAccount
+	err := row.Scan(
+		&a.ID, &a.Name, &a.Description, &a.ClientID, &a.ClientSecretHash,
+		&a.TLSIdentity, &a.IsActive,
+		&a.CreatedAt, &a.UpdatedAt, &a.LastUsedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *serviceAccountRepository) Create(ctx context.Context, account *models.ServiceAccount) error {
+	query := `
+		INSERT INTO service_accounts (name, description, client_id, client_secret_hash, tls_identity)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING ` + serviceAccountColumns
+
+	created, err := scanServiceAccount(r.db.QueryRowContext(
+		ctx, query,
+		account.Name, account.Description, account.ClientID, account.ClientSecretHash,
+		nullString(account.TLSIdentity),
+	))
+	if isUniqueViolation(err) {
+		return ErrServiceAccountAlreadyExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create service account: %w", err)
+	}
+
+	*account = *created
+	return nil
+}
+
+func (r *serviceAccountRepository) GetByClientID(ctx context.Context, clientID string) (*models.ServiceAccount, error) {
+	query := `SELECT ` + serviceAccountColumns + ` FROM service_accounts WHERE client_id = $1`
+
+	account, err := scanServiceAccount(r.db.QueryRowContext(ctx, query, clientID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrServiceAccountNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service account by client id: %w", err)
+	}
+
+	return account, nil
+}
+
+func (r *serviceAccountRepository) GetByTLSIdentity(ctx context.Context, identity string) (*models.ServiceAccount, error) {
+	query := `SELECT ` + serviceAccountColumns + ` FROM service_accounts WHERE tls_identity = $1`
+
+	account, err := scanServiceAccount(r.db.QueryRowContext(ctx, query, identity))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrServiceAccountNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service account by tls identity: %w", err)
+	}
+
+	return account, nil
+}
+
+func (r *serviceAccountRepository) List(ctx context.Context) ([]*models.ServiceAccount, error) {
+	query := `SELECT ` + serviceAccountColumns + ` FROM service_accounts ORDER BY name`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list service accounts: %w", err)
+	}
+	defer rows.Close()
+
+	accounts := make([]*models.ServiceAccount, 0)
+	for rows.Next() {
+		account, err := scanServiceAccount(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan service account: %w", err)
+		}
+		accounts = append(accounts, account)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate service accounts: %w", err)
+	}
+
+	return accounts, nil
+}
+
+// Delete удаляет аккаунт; выданные ему токены удаляются каскадно
+func (r *serviceAccountRepository) Delete(ctx context.Context, id string) error {
+	query := `DELETE FROM service_accounts WHERE id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if isInvalidTextRepresentation(err) {
+		return ErrServiceAccountNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete service account: %w", err)
+	}
+
+	return requireAffected(result, ErrServiceAccountNotFound)
+}
+
+func (r *serviceAccountRepository) CreateToken(ctx context.Context, token *models.ServiceToken) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO service_tokens (service_account_id, token, expires_at)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at`
+
+	err = tx.QueryRowContext(ctx, query, token.ServiceAccountID, token.TokenHash, token.ExpiresAt).
+		Scan(&token.ID, &token.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create service token: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE service_accounts SET last_used_at = CURRENT_TIMESTAMP WHERE id = $1`,
+		token.ServiceAccountID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update service account: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit service token: %w", err)
+	}
+	return nil
+}
+
+func (r *serviceAccountRepository) GetByToken(ctx context.Context, tokenHash string) (*models.ServiceAccount, error) {
+	query := `
+		SELECT ` + serviceAccountColumns + `
+		FROM service_accounts
+		WHERE is_active = true AND id = (
+			SELECT service_account_id FROM service_tokens
+			WHERE token = $1 AND expires_at > CURRENT_TIMESTAMP
+		)`
+
+	account, err := scanServiceAccount(r.db.QueryRowContext(ctx, query, tokenHash))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTokenNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service account by token: %w", err)
+	}
+
+	return account, nil
+}
@@ -3,6 +3,11 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
 
 	"github.com/haqury/user-service/internal/models"
 )
@@ -12,8 +17,26 @@ type UserClientRepository interface {
 	GetByUserID(ctx context.Context, userID string) ([]*models.UserClient, error)
 	Create(ctx context.Context, userClient *models.UserClient) error
 	Update(ctx context.Context, userClient *models.UserClient) error
-	AssignInstance(ctx context.Context, clientID, instanceID string) error
-	UpdateLastSeen(ctx context.Context, clientID string) error
+	// AssignInstance назначает инстанс клиенту без назначения, запоминает кодеки клиента
+	// и начинает отсчет heartbeat заново. Если инстанс уже назначен, возвращает ErrUserClientAssigned
+	AssignInstance(ctx context.Context, userID, clientID, instanceID string, codecs []string) error
+	// UpdateLastSeen записывает heartbeat клиента с назначенным инстансом
+	UpdateLastSeen(ctx context.Context, userID, clientID string) error
+	// Release снимает назначение клиента и освобождает место на инстансе;
+	// возвращает клиента с освобожденным инстансом в AssignedInstanceID
+	Release(ctx context.Context, userID, clientID, reason string) (*models.UserClient, error)
+	// ListByInstance возвращает до limit клиентов, назначенных на инстанс, с id больше afterID
+	// (постраничный обход по id)
+	ListByInstance(ctx context.Context, instanceID, afterID string, limit int) ([]*models.UserClient, error)
+	// MoveAssignment переносит клиента с инстанса from на инстанс to, место на to должно быть
+	// уже занято (ReserveSlot); место на from освобождается. Если клиент уже не назначен на from,
+	// возвращает ErrStreamingSessionNotFound
+	MoveAssignment(ctx context.Context, clientID, fromInstanceID, toInstanceID string) error
+	// ReleaseStale снимает до limit назначений клиентов без heartbeat с seenBefore
+	ReleaseStale(ctx context.Context, seenBefore time.Time, limit int, reason string) ([]*models.UserClient, error)
+	// ReleaseEvicted снимает до limit назначений на инстансы, которые больше не держат
+	// клиентов: maintenance, retired и draining с истекшим сроком
+	ReleaseEvicted(ctx context.Context, limit int, reason string) ([]*models.UserClient, error)
 	Delete(ctx context.Context, id string) error
 }
 
@@ -27,19 +50,22 @@ func NewUserClientRepository(db *sql.DB) UserClientRepository {
 
 func (r *userClientRepository) GetByClientID(ctx context.Context, clientID string) (*models.UserClient, error) {
 	query := `
-		SELECT id, user_id, client_id, client_info, assigned_instance_id,
-		       is_active, created_at, updated_at, last_seen
+		SELECT id, user_id, client_id, client_info, assigned_instance_id, COALESCE(codecs, '{}'),
+		       is_active, created_at, updated_at, last_seen, release_reason, released_at
 		FROM user_clients
 		WHERE client_id = $1 AND is_active = true
 	`
 
 	var uc models.UserClient
 	err := r.db.QueryRowContext(ctx, query, clientID).Scan(
-		&uc.ID, &uc.UserID, &uc.ClientID, &uc.ClientInfo, &uc.AssignedInstanceID,
-		&uc.IsActive, &uc.CreatedAt, &uc.UpdatedAt, &uc.LastSeen,
+		&uc.ID, &uc.UserID, &uc.ClientID, &uc.ClientInfo, &uc.AssignedInstanceID, &uc.Codecs,
+		&uc.IsActive, &uc.CreatedAt, &uc.UpdatedAt, &uc.LastSeen, &uc.ReleaseReason, &uc.ReleasedAt,
 	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUserClientNotFound
+	}
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to get user client: %w", err)
 	}
 
 	return &uc, nil
@@ -52,15 +78,24 @@ func (r *userClientRepository) GetByUserID(ctx context.Context, userID string) (
 
 func (r *userClientRepository) Create(ctx context.Context, userClient *models.UserClient) error {
 	query := `
-		INSERT INTO user_clients (user_id, client_id, client_info, assigned_instance_id)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO user_clients (user_id, client_id, client_info, assigned_instance_id, codecs)
+		VALUES ($1, $2, $3, $4, $5)
 		RETURNING id, created_at, updated_at, last_seen
 	`
 
-	return r.db.QueryRowContext(
+	err := r.db.QueryRowContext(
 		ctx, query,
 		userClient.UserID, userClient.ClientID, userClient.ClientInfo, userClient.AssignedInstanceID,
+		userClient.Codecs,
 	).Scan(&userClient.ID, &userClient.CreatedAt, &userClient.UpdatedAt, &userClient.LastSeen)
+	if isUniqueViolation(err) {
+		return ErrUserClientAlreadyExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create user client: %w", err)
+	}
+
+	return nil
 }
 
 func (r *userClientRepository) Update(ctx context.Context, userClient *models.UserClient) error {
@@ -68,16 +103,222 @@ func (r *userClientRepository) Update(ctx context.Context, userClient *models.Us
 	return nil
 }
 
-func (r *userClientRepository) AssignInstance(ctx context.Context, clientID, instanceID string) error {
-	query := `UPDATE user_clients SET assigned_instance_id = $1 WHERE client_id = $2`
-	_, err := r.db.ExecContext(ctx, query, instanceID, clientID)
-	return err
+func (r *userClientRepository) AssignInstance(ctx context.Context, userID, clientID, instanceID string, codecs []string) error {
+	query := `
+		UPDATE user_clients SET
+			assigned_instance_id = $1,
+			codecs = $4,
+			last_seen = CURRENT_TIMESTAMP,
+			release_reason = NULL,
+			released_at = NULL
+		WHERE client_id = $2 AND user_id = $3 AND assigned_instance_id IS NULL`
+
+	result, err := r.db.ExecContext(ctx, query, instanceID, clientID, userID, pq.StringArray(codecs))
+	if isInvalidTextRepresentation(err) {
+		return ErrUserClientNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to assign instance to user client: %w", err)
+	}
+
+	err = requireAffected(result, ErrUserClientNotFound)
+	if !errors.Is(err, ErrUserClientNotFound) {
+		return err
+	}
+
+	// Отличаем отсутствующего клиента от уже назначенного
+	var exists bool
+	err = r.db.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM user_clients WHERE client_id = $1 AND user_id = $2)`,
+		clientID, userID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check user client: %w", err)
+	}
+	if !exists {
+		return ErrUserClientNotFound
+	}
+	return ErrUserClientAssigned
 }
 
-func (r *userClientRepository) UpdateLastSeen(ctx context.Context, clientID string) error {
-	query := `UPDATE user_clients SET last_seen = CURRENT_TIMESTAMP WHERE client_id = $1`
-	_, err := r.db.ExecContext(ctx, query, clientID)
-	return err
+func (r *userClientRepository) UpdateLastSeen(ctx context.Context, userID, clientID string) error {
+	query := `
+		UPDATE user_clients SET last_seen = CURRENT_TIMESTAMP
+		WHERE client_id = $1 AND user_id = $2 AND is_active = true AND assigned_instance_id IS NOT NULL`
+
+	result, err := r.db.ExecContext(ctx, query, clientID, userID)
+	if isInvalidTextRepresentation(err) {
+		return ErrStreamingSessionNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update client last seen: %w", err)
+	}
+
+	return requireAffected(result, ErrStreamingSessionNotFound)
+}
+
+func (r *userClientRepository) Release(ctx context.Context, userID, clientID, reason string) (*models.UserClient, error) {
+	selected := `
+		SELECT id, assigned_instance_id FROM user_clients
+		WHERE client_id = $2 AND user_id = $3 AND assigned_instance_id IS NOT NULL
+		FOR UPDATE`
+
+	released, err := r.releaseAssignments(ctx, selected, reason, clientID, userID)
+	if isInvalidTextRepresentation(err) {
+		return nil, ErrStreamingSessionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to release user client: %w", err)
+	}
+	if len(released) == 0 {
+		return nil, ErrStreamingSessionNotFound
+	}
+
+	return released[0], nil
+}
+
+func (r *userClientRepository) ReleaseStale(ctx context.Context, seenBefore time.Time, limit int, reason string) ([]*models.UserClient, error) {
+	// SKIP LOCKED: реплики user-service освобождают разные клиенты, не дожидаясь друг друга
+	selected := `
+		SELECT id, assigned_instance_id FROM user_clients
+		WHERE assigned_instance_id IS NOT NULL AND last_seen < $2
+		ORDER BY last_seen
+		LIMIT $3
+		FOR UPDATE SKIP LOCKED`
+
+	released, err := r.releaseAssignments(ctx, selected, reason, seenBefore, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to release stale user clients: %w", err)
+	}
+
+	return released, nil
+}
+
+func (r *userClientRepository) ListByInstance(ctx context.Context, instanceID, afterID string, limit int) ([]*models.UserClient, error) {
+	query := `
+		SELECT id, user_id, client_id, client_info, assigned_instance_id, COALESCE(codecs, '{}'),
+		       is_active, created_at, updated_at, last_seen, release_reason, released_at
+		FROM user_clients
+		WHERE assigned_instance_id = $1 AND id > $2
+		ORDER BY id
+		LIMIT $3`
+
+	if afterID == "" {
+		afterID = "00000000-0000-0000-0000-000000000000"
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, instanceID, afterID, limit)
+	if isInvalidTextRepresentation(err) {
+		return nil, ErrVideoServiceInstanceNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list instance clients: %w", err)
+	}
+	defer rows.Close()
+
+	var clients []*models.UserClient
+	for rows.Next() {
+		var uc models.UserClient
+		if err := rows.Scan(
+			&uc.ID, &uc.UserID, &uc.ClientID, &uc.ClientInfo, &uc.AssignedInstanceID, &uc.Codecs,
+			&uc.IsActive, &uc.CreatedAt, &uc.UpdatedAt, &uc.LastSeen, &uc.ReleaseReason, &uc.ReleasedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan user client: %w", err)
+		}
+		clients = append(clients, &uc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate instance clients: %w", err)
+	}
+
+	return clients, nil
+}
+
+func (r *userClientRepository) MoveAssignment(ctx context.Context, clientID, fromInstanceID, toInstanceID string) error {
+	query := `
+		WITH moved AS (
+			UPDATE user_clients SET assigned_instance_id = $3
+			WHERE client_id = $1 AND assigned_instance_id = $2
+			RETURNING id
+		), freed AS (
+			UPDATE video_service_instances SET current_load = GREATEST(current_load - 1, 0)
+			WHERE id = $2 AND EXISTS (SELECT 1 FROM moved)
+		)
+		SELECT COUNT(*) FROM moved`
+
+	var moved int
+	if err := r.db.QueryRowContext(ctx, query, clientID, fromInstanceID, toInstanceID).Scan(&moved); err != nil {
+		return fmt.Errorf("failed to move user client: %w", err)
+	}
+	if moved == 0 {
+		return ErrStreamingSessionNotFound
+	}
+
+	return nil
+}
+
+func (r *userClientRepository) ReleaseEvicted(ctx context.Context, limit int, reason string) ([]*models.UserClient, error) {
+	selected := `
+		SELECT uc.id, uc.assigned_instance_id FROM user_clients uc
+		JOIN video_service_instances v ON v.id = uc.assigned_instance_id
+		WHERE v.state IN ('maintenance', 'retired')
+		   OR (v.state = 'draining' AND v.drain_deadline < CURRENT_TIMESTAMP)
+		ORDER BY uc.id
+		LIMIT $2
+		FOR UPDATE OF uc SKIP LOCKED`
+
+	released, err := r.releaseAssignments(ctx, selected, reason, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to release evicted user clients: %w", err)
+	}
+
+	return released, nil
+}
+
+// releaseAssignments снимает назначения с клиентов, выбранных запросом selected (id и
+// assigned_instance_id, параметры начинаются с $2), записывает причину ($1) и уменьшает
+// нагрузку инстансов. Все делается одним запросом, поэтому счетчик нагрузки не расходится
+// с назначениями
+func (r *userClientRepository) releaseAssignments(ctx context.Context, selected, reason string, args ...interface{}) ([]*models.UserClient, error) {
+	query := `
+		WITH selected AS (` + selected + `
+		), released AS (
+			UPDATE user_clients uc SET
+				assigned_instance_id = NULL,
+				release_reason = $1,
+				released_at = CURRENT_TIMESTAMP
+			FROM selected
+			WHERE uc.id = selected.id
+			RETURNING uc.id, uc.user_id, uc.client_id, selected.assigned_instance_id,
+			          uc.is_active, uc.last_seen, uc.release_reason, uc.released_at
+		), freed AS (
+			UPDATE video_service_instances v SET current_load = GREATEST(v.current_load - f.clients, 0)
+			FROM (
+				SELECT assigned_instance_id, COUNT(*) AS clients FROM released GROUP BY assigned_instance_id
+			) f
+			WHERE v.id = f.assigned_instance_id
+		)
+		SELECT id, user_id, client_id, assigned_instance_id, is_active, last_seen, release_reason, released_at
+		FROM released`
+
+	rows, err := r.db.QueryContext(ctx, query, append([]interface{}{reason}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var released []*models.UserClient
+	for rows.Next() {
+		uc := &models.UserClient{}
+		if err := rows.Scan(
+			&uc.ID, &uc.UserID, &uc.ClientID, &uc.AssignedInstanceID,
+			&uc.IsActive, &uc.LastSeen, &uc.ReleaseReason, &uc.ReleasedAt,
+		); err != nil {
+			return nil, err
+		}
+		released = append(released, uc)
+	}
+
+	return released, rows.Err()
 }
 
 func (r *userClientRepository) Delete(ctx context.Context, id string) error {
@@ -2,64 +2,312 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/haqury/user-service/internal/models"
 )
 
 type UserRepository interface {
-	GetByID(ctx context.Context, id string) (interface{}, error)
-	GetByUsername(ctx context.Context, username string) (interface{}, error)
-	Create(ctx context.Context, user interface{}) error
-	Update(ctx context.Context, id string, user interface{}) error
+	GetByID(ctx context.Context, id string) (*models.User, error)
+	GetByUsername(ctx context.Context, username string) (*models.User, error)
+	Create(ctx context.Context, user *models.User) error
+	Update(ctx context.Context, user *models.User) error
+	UpdatePasswordHash(ctx context.Context, id, passwordHash string) error
+	// AddRole и RemoveRole идемпотентно меняют users.roles и возвращают обновленного пользователя
+	AddRole(ctx context.Context, id, role string) (*models.User, error)
+	RemoveRole(ctx context.Context, id, role string) (*models.User, error)
+	// RecordLoginSuccess увеличивает stats.successful_logins и обновляет last_login
+	RecordLoginSuccess(ctx context.Context, id string) error
+	// RecordLoginFailure увеличивает stats.failed_logins
+	RecordLoginFailure(ctx context.Context, id string) error
 	Delete(ctx context.Context, id string) error
-	List(ctx context.Context, page, limit int, filter string) ([]interface{}, int, error)
+	List(ctx context.Context, page, limit int, filter string) ([]*models.User, int, error)
+}
+
+type userRepository struct {
+	db *sql.DB
 }
 
-type userRepository struct{}
+func NewUserRepository(db *sql.DB) UserRepository {
+	return &userRepository{db: db}
+}
+
+// userColumns - колонки users в порядке сканирования scanUser.
+// Nullable колонки с DEFAULT приводятся через COALESCE, чтобы не тащить sql.Null* в модель
+const userColumns = `
+	id, username, email, COALESCE(phone, ''), password_hash,
+	COALESCE(status, 'active'), COALESCE(is_active, true), COALESCE(roles, ARRAY[]::TEXT[]),
+	COALESCE(subscription_tier, 'free'), COALESCE(region, 'default'),
+	settings, streaming_config, stats, metadata,
+	created_at, updated_at, last_login, last_activity
+`
+
+// rowScanner - общий интерфейс *sql.Row и *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
 
-func NewUserRepository() UserRepository {
-	return &userRepository{}
+func scanUser(row rowScanner) (*models.User, error) {
+	var u models.User
+	err := row.Scan(
+		&u.ID, &u.Username, &u.Email, &u.Phone, &u.PasswordHash,
+		&u.Status, &u.IsActive, &u.Roles,
+		&u.SubscriptionTier, &u.Region,
+		&u.Settings, &u.StreamingConfig, &u.Stats, &u.Metadata,
+		&u.CreatedAt, &u.UpdatedAt, &u.LastLogin, &u.LastActivity,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
 }
 
-func (r *userRepository) GetByID(ctx context.Context, id string) (interface{}, error) {
-	return map[string]interface{}{
-		"id":       id,
-		"username": "testuser",
-		"email":    "test@example.com",
-		"status":   "active",
-	}, nil
+func (r *userRepository) GetByID(ctx context.Context, id string) (*models.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = $1`
+
+	user, err := scanUser(r.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) || isInvalidTextRepresentation(err) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user by id: %w", err)
+	}
+
+	return user, nil
 }
 
-func (r *userRepository) GetByUsername(ctx context.Context, username string) (interface{}, error) {
-	return map[string]interface{}{
-		"id":       "123",
-		"username": username,
-		"email":    "user@example.com",
-		"status":   "active",
-	}, nil
+func (r *userRepository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE username = $1`
+
+	user, err := scanUser(r.db.QueryRowContext(ctx, query, username))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user by username: %w", err)
+	}
+
+	return user, nil
 }
 
-func (r *userRepository) Create(ctx context.Context, user interface{}) error {
+// Create вставляет пользователя и заполняет модель значениями из БД.
+// Незаданные поля (status, roles, JSONB-колонки и т.д.) получают DEFAULT из миграций
+func (r *userRepository) Create(ctx context.Context, user *models.User) error {
+	columns := []string{"username", "email", "phone", "password_hash", "is_active"}
+	args := []interface{}{user.Username, user.Email, nullString(user.Phone), user.PasswordHash, user.IsActive}
+
+	optional := []struct {
+		column string
+		set    bool
+		value  interface{}
+	}{
+		{"status", user.Status != "", user.Status},
+		{"roles", user.Roles != nil, user.Roles},
+		{"subscription_tier", user.SubscriptionTier != "", user.SubscriptionTier},
+		{"region", user.Region != "", user.Region},
+		{"settings", user.Settings != nil, user.Settings},
+		{"streaming_config", user.StreamingConfig != nil, user.StreamingConfig},
+		{"stats", user.Stats != nil, user.Stats},
+		{"metadata", user.Metadata != nil, user.Metadata},
+	}
+	for _, o := range optional {
+		if o.set {
+			columns = append(columns, o.column)
+			args = append(args, o.value)
+		}
+	}
+
+	query := fmt.Sprintf(
+		`INSERT INTO users (%s) VALUES (%s) RETURNING %s`,
+		strings.Join(columns, ", "), placeholders(1, len(args)), userColumns,
+	)
+
+	created, err := scanUser(r.db.QueryRowContext(ctx, query, args...))
+	if isUniqueViolation(err) {
+		return ErrUserAlreadyExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+
+	*user = *created
 	return nil
 }
 
-func (r *userRepository) Update(ctx context.Context, id string, user interface{}) error {
+// Update сохраняет изменяемые поля пользователя (без пароля и служебных временных меток)
+func (r *userRepository) Update(ctx context.Context, user *models.User) error {
+	query := `
+		UPDATE users SET
+			email = $2, phone = $3, status = $4, is_active = $5, roles = $6,
+			subscription_tier = $7, region = $8,
+			settings = $9, streaming_config = $10, stats = $11, metadata = $12
+		WHERE id = $1
+		RETURNING ` + userColumns
+
+	updated, err := scanUser(r.db.QueryRowContext(
+		ctx, query,
+		user.ID, user.Email, nullString(user.Phone), user.Status, user.IsActive, user.Roles,
+		user.SubscriptionTier, user.Region,
+		user.Settings, user.StreamingConfig, user.Stats, user.Metadata,
+	))
+	if errors.Is(err, sql.ErrNoRows) || isInvalidTextRepresentation(err) {
+		return ErrUserNotFound
+	}
+	if isUniqueViolation(err) {
+		return ErrUserAlreadyExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+
+	*user = *updated
 	return nil
 }
 
+func (r *userRepository) UpdatePasswordHash(ctx context.Context, id, passwordHash string) error {
+	query := `UPDATE users SET password_hash = $2 WHERE id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, id, passwordHash)
+	if isInvalidTextRepresentation(err) {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update password hash: %w", err)
+	}
+
+	return requireAffected(result, ErrUserNotFound)
+}
+
+func (r *userRepository) AddRole(ctx context.Context, id, role string) (*models.User, error) {
+	query := `
+		UPDATE users SET roles = CASE
+			WHEN $2 = ANY(COALESCE(roles, ARRAY[]::TEXT[])) THEN roles
+			ELSE array_append(COALESCE(roles, ARRAY[]::TEXT[]), $2)
+		END
+		WHERE id = $1
+		RETURNING ` + userColumns
+
+	return r.updateRoles(ctx, query, id, role)
+}
+
+func (r *userRepository) RemoveRole(ctx context.Context, id, role string) (*models.User, error) {
+	query := `
+		UPDATE users SET roles = array_remove(COALESCE(roles, ARRAY[]::TEXT[]), $2)
+		WHERE id = $1
+		RETURNING ` + userColumns
+
+	return r.updateRoles(ctx, query, id, role)
+}
+
+func (r *userRepository) updateRoles(ctx context.Context, query, id, role string) (*models.User, error) {
+	user, err := scanUser(r.db.QueryRowContext(ctx, query, id, role))
+	if errors.Is(err, sql.ErrNoRows) || isInvalidTextRepresentation(err) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update user roles: %w", err)
+	}
+	return user, nil
+}
+
+func (r *userRepository) RecordLoginSuccess(ctx context.Context, id string) error {
+	query := `
+		UPDATE users SET
+			stats = jsonb_set(
+				COALESCE(stats, '{}'::JSONB), '{successful_logins}',
+				to_jsonb(COALESCE((stats->>'successful_logins')::INT, 0) + 1)
+			),
+			last_login = CURRENT_TIMESTAMP
+		WHERE id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to record successful login: %w", err)
+	}
+
+	return requireAffected(result, ErrUserNotFound)
+}
+
+func (r *userRepository) RecordLoginFailure(ctx context.Context, id string) error {
+	query := `
+		UPDATE users SET
+			stats = jsonb_set(
+				COALESCE(stats, '{}'::JSONB), '{failed_logins}',
+				to_jsonb(COALESCE((stats->>'failed_logins')::INT, 0) + 1)
+			)
+		WHERE id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to record failed login: %w", err)
+	}
+
+	return requireAffected(result, ErrUserNotFound)
+}
+
 func (r *userRepository) Delete(ctx context.Context, id string) error {
-	return nil
+	query := `DELETE FROM users WHERE id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if isInvalidTextRepresentation(err) {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+
+	return requireAffected(result, ErrUserNotFound)
 }
 
-func (r *userRepository) List(ctx context.Context, page, limit int, filter string) ([]interface{}, int, error) {
-	return []interface{}{
-		map[string]interface{}{
-			"id":       "1",
-			"username": "user1",
-			"email":    "user1@example.com",
-		},
-		map[string]interface{}{
-			"id":       "2",
-			"username": "user2",
-			"email":    "user2@example.com",
-		},
-	}, 2, nil
+// List возвращает страницу пользователей и общее количество.
+// filter ищет подстроку в username и email (без учета регистра)
+func (r *userRepository) List(ctx context.Context, page, limit int, filter string) ([]*models.User, int, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 20
+	}
+
+	where := ""
+	args := []interface{}{}
+	if filter != "" {
+		where = `WHERE username ILIKE $1 OR email ILIKE $1`
+		args = append(args, "%"+filter+"%")
+	}
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM users ` + where
+	if err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	query := fmt.Sprintf(
+		`SELECT %s FROM users %s ORDER BY created_at DESC LIMIT $%d OFFSET $%d`,
+		userColumns, where, len(args)+1, len(args)+2,
+	)
+	args = append(args, limit, (page-1)*limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list users: %w", err)
+	}
+	defer rows.Close()
+
+	users := make([]*models.User, 0, limit)
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to iterate users: %w", err)
+	}
+
+	return users, total, nil
 }
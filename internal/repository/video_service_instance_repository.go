@@ -3,6 +3,11 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
 
 	"github.com/haqury/user-service/internal/models"
 )
@@ -13,6 +18,20 @@ type VideoServiceInstanceRepository interface {
 	GetActiveInstances(ctx context.Context) ([]*models.VideoServiceInstance, error)
 	GetByRegionAndTier(ctx context.Context, region, tier string) ([]*models.VideoServiceInstance, error)
 	UpdateLoad(ctx context.Context, id string, load int32) error
+	// UpdateHealth записывает результат проверки здоровья и время проверки
+	UpdateHealth(ctx context.Context, id, status string) error
+	// Heartbeat записывает нагрузку, сообщенную инстансом, в reported_load; current_load
+	// (места, занятые ReserveSlot) не меняется. capacity 0 - емкость не меняется
+	Heartbeat(ctx context.Context, id string, load, capacity int32) (*models.VideoServiceInstance, error)
+	// ReserveSlot атомарно занимает место на здоровом инстансе со свободной емкостью
+	// и возвращает ErrInstanceFull, если места нет
+	ReserveSlot(ctx context.Context, id string) (*models.VideoServiceInstance, error)
+	// ReleaseSlot освобождает место, занятое ReserveSlot
+	ReleaseSlot(ctx context.Context, id string) error
+	// SetState переводит инстанс в состояние state, если переход из текущего состояния
+	// разрешен (models.InstanceStateTransitions), иначе возвращает ErrInstanceStateTransition.
+	// drainDeadline записывается только для draining
+	SetState(ctx context.Context, id, state string, drainDeadline *time.Time) (*models.VideoServiceInstance, error)
 	Create(ctx context.Context, instance *models.VideoServiceInstance) error
 	Update(ctx context.Context, instance *models.VideoServiceInstance) error
 	Delete(ctx context.Context, id string) error
@@ -26,58 +45,324 @@ func NewVideoServiceInstanceRepository(db *sql.DB) VideoServiceInstanceRepositor
 	return &videoServiceInstanceRepository{db: db}
 }
 
+// videoServiceInstanceColumns - колонки с DEFAULT из миграции 008 могут быть NULL
+// у записей, вставленных с явным NULL, поэтому приводятся к значениям по умолчанию
+const videoServiceInstanceColumns = `
+	id, name, server_url, server_port, COALESCE(use_ssl, true),
+	COALESCE(stream_endpoint, '/stream'), COALESCE(region, 'default'),
+	COALESCE(priority, 0), COALESCE(max_capacity, 1000), COALESCE(current_load, 0), reported_load,
+	COALESCE(health_status, 'healthy'), COALESCE(allowed_tiers, '{}'),
+	COALESCE(max_bitrate, 5000), COALESCE(max_resolution, 1080), COALESCE(codec, 'h264'),
+	COALESCE(metadata, '{}'), COALESCE(is_active, true),
+	created_at, updated_at, last_health_check, last_heartbeat,
+	state, state_changed_at, drain_deadline
+`
+
+func scanVideoServiceInstance(row rowScanner) (*models.VideoServiceInstance, error) {
+	var i models.VideoServiceInstance
+	err := row.Scan(
+		&i.ID, &i.Name, &i.ServerURL, &i.ServerPort, &i.UseSSL,
+		&i.StreamEndpoint, &i.Region,
+		&i.Priority, &i.MaxCapacity, &i.CurrentLoad, &i.ReportedLoad,
+		&i.HealthStatus, &i.AllowedTiers,
+		&i.MaxBitrate, &i.MaxResolution, &i.Codec,
+		&i.Metadata, &i.IsActive,
+		&i.CreatedAt, &i.UpdatedAt, &i.LastHealthCheck, &i.LastHeartbeat,
+		&i.State, &i.StateChangedAt, &i.DrainDeadline,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &i, nil
+}
+
 func (r *videoServiceInstanceRepository) GetByID(ctx context.Context, id string) (*models.VideoServiceInstance, error) {
-	// TODO: implement
-	return nil, nil
+	query := `SELECT ` + videoServiceInstanceColumns + ` FROM video_service_instances WHERE id = $1`
+
+	instance, err := scanVideoServiceInstance(r.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) || isInvalidTextRepresentation(err) {
+		return nil, ErrVideoServiceInstanceNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get video service instance: %w", err)
+	}
+
+	return instance, nil
 }
 
 func (r *videoServiceInstanceRepository) GetByName(ctx context.Context, name string) (*models.VideoServiceInstance, error) {
-	// TODO: implement
-	return nil, nil
+	query := `SELECT ` + videoServiceInstanceColumns + ` FROM video_service_instances WHERE name = $1`
+
+	instance, err := scanVideoServiceInstance(r.db.QueryRowContext(ctx, query, name))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrVideoServiceInstanceNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get video service instance by name: %w", err)
+	}
+
+	return instance, nil
 }
 
 func (r *videoServiceInstanceRepository) GetActiveInstances(ctx context.Context) ([]*models.VideoServiceInstance, error) {
-	// TODO: implement
-	return nil, nil
+	query := `
+		SELECT ` + videoServiceInstanceColumns + `
+		FROM video_service_instances
+		WHERE is_active = true AND state <> 'retired'
+		ORDER BY region, priority DESC, name`
+
+	return r.list(ctx, query)
 }
 
+// GetByRegionAndTier возвращает здоровые инстансы региона в состоянии active, обслуживающие тариф
+// и имеющие свободную емкость, в порядке предпочтения для роутинга
 func (r *videoServiceInstanceRepository) GetByRegionAndTier(ctx context.Context, region, tier string) ([]*models.VideoServiceInstance, error) {
 	query := `
-		SELECT id, name, server_url, server_port, use_ssl, stream_endpoint, region,
-		       priority, max_capacity, current_load, health_status, allowed_tiers,
-		       max_bitrate, max_resolution, codec, metadata, is_active,
-		       created_at, updated_at, last_health_check
+		SELECT ` + videoServiceInstanceColumns + `
 		FROM video_service_instances
 		WHERE is_active = true
+		  AND state = 'active'
 		  AND health_status = 'healthy'
 		  AND region = $1
 		  AND $2 = ANY(allowed_tiers)
 		  AND current_load < max_capacity
-		ORDER BY priority DESC, current_load ASC
-	`
+		ORDER BY priority DESC, current_load ASC`
 
-	// TODO: implement query execution
-	_ = query
-	return nil, nil
+	return r.list(ctx, query, region, tier)
 }
 
 func (r *videoServiceInstanceRepository) UpdateLoad(ctx context.Context, id string, load int32) error {
 	query := `UPDATE video_service_instances SET current_load = $1 WHERE id = $2`
-	_, err := r.db.ExecContext(ctx, query, load, id)
-	return err
+
+	result, err := r.db.ExecContext(ctx, query, load, id)
+	if isInvalidTextRepresentation(err) {
+		return ErrVideoServiceInstanceNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update video service instance load: %w", err)
+	}
+
+	return requireAffected(result, ErrVideoServiceInstanceNotFound)
+}
+
+func (r *videoServiceInstanceRepository) UpdateHealth(ctx context.Context, id, status string) error {
+	query := `
+		UPDATE video_service_instances
+		SET health_status = $1, last_health_check = CURRENT_TIMESTAMP
+		WHERE id = $2`
+
+	result, err := r.db.ExecContext(ctx, query, status, id)
+	if isInvalidTextRepresentation(err) {
+		return ErrVideoServiceInstanceNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update video service instance health: %w", err)
+	}
+
+	return requireAffected(result, ErrVideoServiceInstanceNotFound)
+}
+
+func (r *videoServiceInstanceRepository) Heartbeat(ctx context.Context, id string, load, capacity int32) (*models.VideoServiceInstance, error) {
+	query := `
+		UPDATE video_service_instances SET
+			reported_load = $2,
+			max_capacity = COALESCE($3, max_capacity),
+			last_heartbeat = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING ` + videoServiceInstanceColumns
+
+	instance, err := scanVideoServiceInstance(r.db.QueryRowContext(ctx, query, id, load, nullInt32(capacity)))
+	if errors.Is(err, sql.ErrNoRows) || isInvalidTextRepresentation(err) {
+		return nil, ErrVideoServiceInstanceNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to record video service instance heartbeat: %w", err)
+	}
+
+	return instance, nil
+}
+
+// ReserveSlot проверяет емкость и увеличивает нагрузку одним UPDATE, поэтому из
+// параллельных запросов за последнее место успешен только один
+func (r *videoServiceInstanceRepository) ReserveSlot(ctx context.Context, id string) (*models.VideoServiceInstance, error) {
+	query := `
+		UPDATE video_service_instances SET current_load = current_load + 1
+		WHERE id = $1
+		  AND is_active = true
+		  AND state = 'active'
+		  AND health_status = 'healthy'
+		  AND current_load < max_capacity
+		RETURNING ` + videoServiceInstanceColumns
+
+	instance, err := scanVideoServiceInstance(r.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) || isInvalidTextRepresentation(err) {
+		return nil, ErrInstanceFull
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve video service instance slot: %w", err)
+	}
+
+	return instance, nil
+}
+
+func (r *videoServiceInstanceRepository) ReleaseSlot(ctx context.Context, id string) error {
+	query := `
+		UPDATE video_service_instances SET current_load = GREATEST(current_load - 1, 0)
+		WHERE id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if isInvalidTextRepresentation(err) {
+		return ErrVideoServiceInstanceNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to release video service instance slot: %w", err)
+	}
+
+	return requireAffected(result, ErrVideoServiceInstanceNotFound)
+}
+
+// SetState проверяет текущее состояние в том же UPDATE, поэтому параллельные смены
+// состояния не обходят таблицу переходов
+func (r *videoServiceInstanceRepository) SetState(ctx context.Context, id, state string, drainDeadline *time.Time) (*models.VideoServiceInstance, error) {
+	if state != models.InstanceStateDraining {
+		drainDeadline = nil
+	}
+
+	query := `
+		UPDATE video_service_instances SET
+			state = $2,
+			state_changed_at = CURRENT_TIMESTAMP,
+			drain_deadline = $3
+		WHERE id = $1 AND state = ANY($4::TEXT[])
+		RETURNING ` + videoServiceInstanceColumns
+
+	instance, err := scanVideoServiceInstance(r.db.QueryRowContext(
+		ctx, query, id, state, drainDeadline, pq.StringArray(models.InstanceStateSources(state)),
+	))
+	if isInvalidTextRepresentation(err) {
+		return nil, ErrVideoServiceInstanceNotFound
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		// Отличаем отсутствующий инстанс от запрещенного перехода
+		if _, getErr := r.GetByID(ctx, id); getErr != nil {
+			return nil, getErr
+		}
+		return nil, ErrInstanceStateTransition
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to set video service instance state: %w", err)
+	}
+
+	return instance, nil
 }
 
+// Create сохраняет инстанс; пустые необязательные поля получают значения по умолчанию
+// из миграции 008
 func (r *videoServiceInstanceRepository) Create(ctx context.Context, instance *models.VideoServiceInstance) error {
-	// TODO: implement
+	query := `
+		INSERT INTO video_service_instances (
+			name, server_url, server_port, use_ssl, stream_endpoint, region,
+			priority, max_capacity, health_status, allowed_tiers,
+			max_bitrate, max_resolution, codec, metadata, is_active
+		)
+		VALUES (
+			$1, $2, $3, $4, COALESCE($5, '/stream'), COALESCE($6, 'default'),
+			$7, COALESCE($8, 1000), COALESCE($9, 'healthy'),
+			COALESCE($10::TEXT[], ARRAY['free', 'basic', 'premium', 'enterprise']),
+			COALESCE($11, 5000), COALESCE($12, 1080), COALESCE($13, 'h264'),
+			COALESCE($14::JSONB, '{}'), $15
+		)
+		RETURNING ` + videoServiceInstanceColumns
+
+	created, err := scanVideoServiceInstance(r.db.QueryRowContext(
+		ctx, query,
+		instance.Name, instance.ServerURL, instance.ServerPort, instance.UseSSL,
+		nullString(instance.StreamEndpoint), nullString(instance.Region),
+		instance.Priority, nullInt32(instance.MaxCapacity), nullString(instance.HealthStatus),
+		nullStringArray(instance.AllowedTiers),
+		nullInt32(instance.MaxBitrate), nullInt32(instance.MaxResolution), nullString(instance.Codec),
+		instance.Metadata, instance.IsActive,
+	))
+	if isUniqueViolation(err) {
+		return ErrVideoServiceInstanceAlreadyExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create video service instance: %w", err)
+	}
+
+	*instance = *created
 	return nil
 }
 
+// Update перезаписывает настройки инстанса. Нагрузка и время проверки здоровья
+// меняются отдельно (UpdateLoad), чтобы не затереть параллельные обновления
 func (r *videoServiceInstanceRepository) Update(ctx context.Context, instance *models.VideoServiceInstance) error {
-	// TODO: implement
+	query := `
+		UPDATE video_service_instances SET
+			name = $2, server_url = $3, server_port = $4, use_ssl = $5,
+			stream_endpoint = $6, region = $7, priority = $8, max_capacity = $9,
+			health_status = $10, allowed_tiers = $11::TEXT[],
+			max_bitrate = $12, max_resolution = $13, codec = $14,
+			metadata = COALESCE($15::JSONB, '{}'), is_active = $16
+		WHERE id = $1
+		RETURNING ` + videoServiceInstanceColumns
+
+	updated, err := scanVideoServiceInstance(r.db.QueryRowContext(
+		ctx, query,
+		instance.ID, instance.Name, instance.ServerURL, instance.ServerPort, instance.UseSSL,
+		instance.StreamEndpoint, instance.Region, instance.Priority, instance.MaxCapacity,
+		instance.HealthStatus, instance.AllowedTiers,
+		instance.MaxBitrate, instance.MaxResolution, instance.Codec,
+		instance.Metadata, instance.IsActive,
+	))
+	if errors.Is(err, sql.ErrNoRows) || isInvalidTextRepresentation(err) {
+		return ErrVideoServiceInstanceNotFound
+	}
+	if isUniqueViolation(err) {
+		return ErrVideoServiceInstanceAlreadyExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update video service instance: %w", err)
+	}
+
+	*instance = *updated
 	return nil
 }
 
+// Delete удаляет инстанс; назначенные на него клиенты теряют назначение
+// (user_clients.assigned_instance_id ON DELETE SET NULL) и будут перенаправлены
 func (r *videoServiceInstanceRepository) Delete(ctx context.Context, id string) error {
-	// TODO: implement
-	return nil
+	query := `DELETE FROM video_service_instances WHERE id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if isInvalidTextRepresentation(err) {
+		return ErrVideoServiceInstanceNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete video service instance: %w", err)
+	}
+
+	return requireAffected(result, ErrVideoServiceInstanceNotFound)
+}
+
+func (r *videoServiceInstanceRepository) list(ctx context.Context, query string, args ...interface{}) ([]*models.VideoServiceInstance, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list video service instances: %w", err)
+	}
+	defer rows.Close()
+
+	instances := make([]*models.VideoServiceInstance, 0)
+	for rows.Next() {
+		instance, err := scanVideoServiceInstance(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan video service instance: %w", err)
+		}
+		instances = append(instances, instance)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate video service instances: %w", err)
+	}
+
+	return instances, nil
 }
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/haqury/user-service/internal/auth"
+	"github.com/haqury/user-service/internal/config"
+	"github.com/haqury/user-service/internal/models"
+	"github.com/haqury/user-service/internal/repository"
+)
+
+var (
+	// ErrInvalidAPIKey - ключ не найден, отозван или истек
+	ErrInvalidAPIKey = errors.New("invalid or expired api key")
+
+	// ErrAPIKeyScopeDenied - ключ действителен, но не допускает запрошенную область действия
+	ErrAPIKeyScopeDenied = errors.New("api key does not allow requested scope")
+
+	// ErrInvalidAPIKeyScope - неизвестная область действия при создании ключа
+	ErrInvalidAPIKeyScope = errors.New("unknown api key scope")
+
+	// ErrInvalidAPIKeyName - слишком длинное имя ключа
+	ErrInvalidAPIKeyName = errors.New("api key name must be at most 100 characters")
+
+	// ErrInvalidAPIKeyTTL - запрошенный срок действия ключа вне допустимого диапазона
+	ErrInvalidAPIKeyTTL = errors.New("api key ttl is out of allowed range")
+)
+
+// apiKeyPrefixLen - длина видимой части ключа, сохраняемой для опознания в списке
+const apiKeyPrefixLen = len(auth.APIKeyPrefix) + 8
+
+// clientAPIKeyName - имя ключа, выдаваемого клиенту стриминга
+const clientAPIKeyName = "streaming client"
+
+// maxAPIKeyNameLen - ограничение колонки auth_tokens.name
+const maxAPIKeyNameLen = 100
+
+// CreateAPIKeyParams - входные данные для создания ключа
+type CreateAPIKeyParams struct {
+	UserID string
+	Name   string
+	Scopes []string
+	// TTL - срок действия; 0 - срок по умолчанию из конфигурации
+	TTL time.Duration
+}
+
+// VerifiedAPIKey - результат проверки ключа: ключ и его владелец
+type VerifiedAPIKey struct {
+	Key  *models.APIKey
+	User *models.User
+}
+
+// APIKeyService управляет API ключами, которыми клиенты стриминга подключаются к video-service
+type APIKeyService interface {
+	// Create создает ключ и возвращает его значение; ключ не хранится
+	// и больше не может быть получен
+	Create(ctx context.Context, params CreateAPIKeyParams) (*models.APIKey, string, error)
+	List(ctx context.Context, userID string) ([]*models.APIKey, error)
+	Revoke(ctx context.Context, userID, keyID string) error
+
+	// IssueClientKey выдает ключ клиенту стриминга; предыдущий ключ клиента отзывается
+	IssueClientKey(ctx context.Context, userID, clientID string) (string, error)
+	// Verify проверяет ключ и область действия scope (пустая - без проверки области)
+	Verify(ctx context.Context, apiKey, scope string) (*VerifiedAPIKey, error)
+}
+
+type apiKeyService struct {
+	repo     repository.APIKeyRepository
+	userRepo repository.UserRepository
+	config   config.APIKeyConfig
+}
+
+func NewAPIKeyService(repo repository.APIKeyRepository, userRepo repository.UserRepository, cfg config.APIKeyConfig) APIKeyService {
+	return &apiKeyService{repo: repo, userRepo: userRepo, config: cfg}
+}
+
+func (s *apiKeyService) Create(ctx context.Context, params CreateAPIKeyParams) (*models.APIKey, string, error) {
+	if utf8.RuneCountInString(params.Name) > maxAPIKeyNameLen {
+		return nil, "", ErrInvalidAPIKeyName
+	}
+
+	scopes := params.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{models.APIKeyScopeStreamPublish}
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(models.APIKeyScopes, scope) {
+			return nil, "", ErrInvalidAPIKeyScope
+		}
+	}
+
+	ttl := params.TTL
+	if ttl == 0 {
+		ttl = s.config.DefaultTTL
+	}
+	if ttl < 0 || (s.config.MaxTTL > 0 && ttl > s.config.MaxTTL) {
+		return nil, "", ErrInvalidAPIKeyTTL
+	}
+
+	key := &models.APIKey{
+		UserID:    params.UserID,
+		Name:      params.Name,
+		Scopes:    slices.Compact(slices.Sorted(slices.Values(scopes))),
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	value, err := s.store(ctx, key)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return key, value, nil
+}
+
+func (s *apiKeyService) List(ctx context.Context, userID string) ([]*models.APIKey, error) {
+	return s.repo.ListByUser(ctx, userID)
+}
+
+func (s *apiKeyService) Revoke(ctx context.Context, userID, keyID string) error {
+	return s.repo.Revoke(ctx, userID, keyID)
+}
+
+func (s *apiKeyService) IssueClientKey(ctx context.Context, userID, clientID string) (string, error) {
+	key := &models.APIKey{
+		UserID:    userID,
+		Name:      clientAPIKeyName,
+		Scopes:    []string{models.APIKeyScopeStreamPublish},
+		ClientID:  clientID,
+		ExpiresAt: time.Now().Add(s.config.DefaultTTL),
+	}
+	return s.store(ctx, key)
+}
+
+func (s *apiKeyService) Verify(ctx context.Context, apiKey, scope string) (*VerifiedAPIKey, error) {
+	if !strings.HasPrefix(apiKey, auth.APIKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	key, err := s.repo.Use(ctx, auth.HashToken(apiKey))
+	if errors.Is(err, repository.ErrAPIKeyNotFound) {
+		return nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, key.UserID)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !isUserActive(user) {
+		return nil, ErrUserInactive
+	}
+
+	if scope != "" && !key.HasScope(scope) {
+		return nil, ErrAPIKeyScopeDenied
+	}
+
+	return &VerifiedAPIKey{Key: key, User: user}, nil
+}
+
+// store генерирует значение ключа и сохраняет его хэш; возвращает значение
+func (s *apiKeyService) store(ctx context.Context, key *models.APIKey) (string, error) {
+	secret, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+	value := auth.APIKeyPrefix + secret
+
+	key.KeyHash = auth.HashToken(value)
+	key.Prefix = value[:apiKeyPrefixLen]
+	if err := s.repo.Create(ctx, key); err != nil {
+		return "", err
+	}
+
+	return value, nil
+}
@@ -2,44 +2,554 @@ package service
 
 import (
 	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/haqury/user-service/internal/auth"
+	"github.com/haqury/user-service/internal/config"
+	"github.com/haqury/user-service/internal/models"
 	"github.com/haqury/user-service/internal/repository"
+	"github.com/haqury/user-service/internal/throttle"
+)
+
+var (
+	// ErrInvalidCredentials - неверный логин или пароль
+	ErrInvalidCredentials = errors.New("invalid username or password")
+
+	// ErrInvalidToken - токен не найден, отозван или истек
+	ErrInvalidToken = errors.New("invalid or expired token")
+
+	// ErrUserInactive - пользователь заблокирован или деактивирован
+	ErrUserInactive = errors.New("user is not active")
+
+	// ErrRefreshTokenReused - предъявлен уже использованный refresh токен; семейство отозвано
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected, session revoked")
 )
 
 type AuthService interface {
-	Login(ctx context.Context, username, password string) (string, interface{}, error)
-	ValidateToken(ctx context.Context, token string) (bool, interface{}, error)
-	Logout(ctx context.Context, token string) error
+	Login(ctx context.Context, params LoginParams) (*LoginResult, error)
+	RefreshToken(ctx context.Context, refreshToken string) (*LoginResult, error)
+	ValidateToken(ctx context.Context, token string) (*models.User, error)
+	// Authenticate проверяет токен пользователя или сервиса и возвращает вызывающего для interceptors
+	Authenticate(ctx context.Context, token string) (*auth.Principal, error)
+	// AuthenticatePeer находит сервисный аккаунт по идентичности клиентского сертификата mTLS
+	AuthenticatePeer(ctx context.Context, identity string) (*auth.Principal, error)
+
+	// IssueServiceToken выдает токен сервисному аккаунту по client credentials
+	IssueServiceToken(ctx context.Context, clientID, clientSecret string) (*ServiceTokenResult, error)
+	// Logout отзывает токен; revokeFamily дополнительно отзывает все токены его сессии
+	Logout(ctx context.Context, token string, revokeFamily bool) error
+
+	// Сессии пользователя (семейства токенов)
+	ListSessions(ctx context.Context, userID string) ([]*models.Session, error)
+	RevokeSession(ctx context.Context, userID, sessionID string) error
+	RevokeAllSessions(ctx context.Context, userID string) (int, error)
+}
+
+// LoginParams - входные данные для Login
+type LoginParams struct {
+	Username   string
+	Password   string
+	ClientInfo models.JSONB
+	UserAgent  string
+	IPAddress  string
+}
+
+// LoginResult - выданная пара токенов и пользователь
+type LoginResult struct {
+	AccessToken      string
+	TokenType        string
+	ExpiresAt        time.Time
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+	User             *models.User
+}
+
+// ServiceTokenResult - токен, выданный сервисному аккаунту
+type ServiceTokenResult struct {
+	AccessToken string
+	ExpiresAt   time.Time
+	Account     *models.ServiceAccount
+}
+
+// sessionInfo - данные сессии, переносимые между ротациями токенов
+type sessionInfo struct {
+	FamilyID   string
+	ClientInfo models.JSONB
+	UserAgent  string
+	IPAddress  string
 }
 
 type authService struct {
-	authRepo repository.AuthRepository
-	userRepo repository.UserRepository
+	authRepo    repository.AuthRepository
+	userRepo    repository.UserRepository
+	serviceRepo repository.ServiceAccountRepository
+	config      config.AuthConfig
+	jwt         *auth.JWTManager
+	hasher      auth.PasswordHasher
+	throttle    *throttle.LoginThrottler
+}
+
+// NewAuthService создает сервис аутентификации. jwtManager обязателен в режиме jwt,
+// в режиме opaque может быть nil. throttler nil отключает защиту от перебора
+func NewAuthService(
+	authRepo repository.AuthRepository,
+	userRepo repository.UserRepository,
+	serviceRepo repository.ServiceAccountRepository,
+	cfg config.AuthConfig,
+	jwtManager *auth.JWTManager,
+	hasher auth.PasswordHasher,
+	throttler *throttle.LoginThrottler,
+) AuthService {
+	return &authService{
+		authRepo:    authRepo,
+		userRepo:    userRepo,
+		serviceRepo: serviceRepo,
+		config:      cfg,
+		jwt:         jwtManager,
+		hasher:      hasher,
+		throttle:    throttler,
+	}
+}
+
+func (s *authService) Login(ctx context.Context, params LoginParams) (*LoginResult, error) {
+	attempt, err := s.reserveAttempt(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByUsername(ctx, params.Username)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		// Хэшируем пароль и для несуществующего пользователя, чтобы время ответа
+		// не выдавало, зарегистрирован ли username
+		_, _ = s.hasher.Hash(params.Password)
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		s.refundAttempt(ctx, attempt)
+		return nil, err
+	}
+
+	ok, err := s.hasher.Verify(params.Password, user.PasswordHash)
+	if err != nil {
+		log.Printf("Failed to verify password for user %s: %v", user.ID, err)
+	}
+	if !ok {
+		s.loginFailed(ctx, user)
+		return nil, ErrInvalidCredentials
+	}
+
+	if !isUserActive(user) {
+		// Пароль верный - это не перебор, попытка возвращается
+		s.refundAttempt(ctx, attempt)
+		return nil, ErrUserInactive
+	}
+
+	s.loginSucceeded(ctx, user, attempt)
+	s.rehashPassword(ctx, user, params.Password)
+
+	return s.issueTokens(ctx, user, sessionInfo{
+		ClientInfo: params.ClientInfo,
+		UserAgent:  params.UserAgent,
+		IPAddress:  params.IPAddress,
+	})
+}
+
+// reserveAttempt учитывает попытку в ограничителе до проверки пароля и отклоняет ее,
+// если username или IP временно заблокированы. Недоступность хранилища счетчиков
+// не блокирует вход (попытка тогда не учитывается, nil)
+func (s *authService) reserveAttempt(ctx context.Context, params LoginParams) (*throttle.Attempt, error) {
+	if s.throttle == nil {
+		return nil, nil
+	}
+
+	attempt, err := s.throttle.Reserve(ctx, params.Username, params.IPAddress)
+	var throttled *throttle.ThrottledError
+	if errors.As(err, &throttled) {
+		return nil, throttled
+	}
+	if err != nil {
+		log.Printf("Login throttle check failed: %v", err)
+	}
+	return attempt, nil
+}
+
+// refundAttempt возвращает попытку, зарезервированную reserveAttempt, если она
+// не закончилась проверкой неверного пароля
+func (s *authService) refundAttempt(ctx context.Context, attempt *throttle.Attempt) {
+	if attempt == nil {
+		return
+	}
+	if err := s.throttle.Refund(ctx, attempt); err != nil {
+		log.Printf("Failed to refund login attempt: %v", err)
+	}
+}
+
+// loginFailed учитывает неудачную попытку в статистике пользователя; в ограничителе
+// она уже учтена reserveAttempt
+func (s *authService) loginFailed(ctx context.Context, user *models.User) {
+	if err := s.userRepo.RecordLoginFailure(ctx, user.ID); err != nil {
+		log.Printf("Failed to update login stats for user %s: %v", user.ID, err)
+	}
+}
+
+// loginSucceeded сбрасывает счетчик ошибок username и обновляет статистику входов
+func (s *authService) loginSucceeded(ctx context.Context, user *models.User, attempt *throttle.Attempt) {
+	if attempt != nil {
+		if err := s.throttle.Success(ctx, attempt); err != nil {
+			log.Printf("Failed to reset login failures: %v", err)
+		}
+	}
+	if err := s.userRepo.RecordLoginSuccess(ctx, user.ID); err != nil {
+		log.Printf("Failed to update login stats for user %s: %v", user.ID, err)
+	}
+}
+
+// rehashPassword пересчитывает хэш, если он получен устаревшим алгоритмом или параметрами.
+// Ошибка не прерывает вход: хэш будет пересчитан при следующем успешном входе
+func (s *authService) rehashPassword(ctx context.Context, user *models.User, password string) {
+	if !s.hasher.NeedsRehash(user.PasswordHash) {
+		return
+	}
+
+	hash, err := s.hasher.Hash(password)
+	if err == nil {
+		err = s.userRepo.UpdatePasswordHash(ctx, user.ID, hash)
+	}
+	if err != nil {
+		log.Printf("Failed to rehash password for user %s: %v", user.ID, err)
+		return
+	}
+	user.PasswordHash = hash
+}
+
+// RefreshToken обменивает refresh токен на новую пару. Каждый refresh токен одноразовый:
+// повторное предъявление использованного токена означает, что им завладел кто-то еще,
+// поэтому отзывается все семейство - и у атакующего, и у легитимного клиента
+func (s *authService) RefreshToken(ctx context.Context, refreshToken string) (*LoginResult, error) {
+	refreshHash := auth.HashToken(refreshToken)
+
+	consumed, err := s.authRepo.ConsumeRefreshToken(ctx, refreshHash)
+	if errors.Is(err, repository.ErrTokenNotFound) {
+		return nil, s.rejectRefresh(ctx, refreshHash)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, consumed.UserID)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !isUserActive(user) {
+		return nil, ErrUserInactive
+	}
+
+	return s.issueTokens(ctx, user, sessionInfo{
+		FamilyID:   consumed.FamilyID,
+		ClientInfo: consumed.ClientInfo,
+		UserAgent:  consumed.UserAgent,
+		IPAddress:  consumed.IPAddress,
+	})
+}
+
+// rejectRefresh определяет причину отказа и при повторном использовании отзывает семейство
+func (s *authService) rejectRefresh(ctx context.Context, refreshHash string) error {
+	stored, err := s.authRepo.GetByRefreshToken(ctx, refreshHash)
+	if errors.Is(err, repository.ErrTokenNotFound) {
+		return ErrInvalidToken
+	}
+	if err != nil {
+		return err
+	}
+
+	if stored.RefreshUsedAt == nil {
+		// Истек или отозван, но не переиспользован
+		return ErrInvalidToken
+	}
+
+	log.Printf("Refresh token reuse detected: user=%s family=%s", stored.UserID, stored.FamilyID)
+	if err := s.authRepo.RevokeFamily(ctx, stored.FamilyID); err != nil {
+		return fmt.Errorf("failed to revoke token family: %w", err)
+	}
+	return ErrRefreshTokenReused
+}
+
+func (s *authService) ValidateToken(ctx context.Context, token string) (*models.User, error) {
+	tokenHash, err := s.storageKey(token)
+	if err != nil {
+		return nil, err
+	}
+
+	// Для JWT запись в auth_tokens нужна для отзыва и учета last_used_at
+	stored, err := s.authRepo.ValidateToken(ctx, tokenHash)
+	if errors.Is(err, repository.ErrTokenNotFound) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, stored.UserID)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !isUserActive(user) {
+		return nil, ErrUserInactive
+	}
+
+	return user, nil
+}
+
+func (s *authService) Authenticate(ctx context.Context, token string) (*auth.Principal, error) {
+	// Непрозрачный токен пользователя (base64url) тоже может начинаться с префикса
+	// сервиса, поэтому ненайденный токен сервиса проверяется как пользовательский
+	if strings.HasPrefix(token, auth.ServiceTokenPrefix) {
+		account, err := s.serviceRepo.GetByToken(ctx, auth.HashToken(token))
+		if err == nil {
+			return servicePrincipal(account), nil
+		}
+		if !errors.Is(err, repository.ErrTokenNotFound) {
+			return nil, err
+		}
+	}
+
+	user, err := s.ValidateToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	return &auth.Principal{
+		Type:             auth.PrincipalUser,
+		UserID:           user.ID,
+		Roles:            user.Roles,
+		SubscriptionTier: user.SubscriptionTier,
+		Region:           user.Region,
+	}, nil
+}
+
+func (s *authService) AuthenticatePeer(ctx context.Context, identity string) (*auth.Principal, error) {
+	account, err := s.serviceRepo.GetByTLSIdentity(ctx, identity)
+	if errors.Is(err, repository.ErrServiceAccountNotFound) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !account.IsActive {
+		return nil, ErrUserInactive
+	}
+
+	return servicePrincipal(account), nil
+}
+
+// IssueServiceToken проверяет client_secret за постоянное время и выдает непрозрачный
+// токен с префиксом auth.ServiceTokenPrefix
+func (s *authService) IssueServiceToken(ctx context.Context, clientID, clientSecret string) (*ServiceTokenResult, error) {
+	account, err := s.serviceRepo.GetByClientID(ctx, clientID)
+	if errors.Is(err, repository.ErrServiceAccountNotFound) {
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	secretHash := auth.HashToken(clientSecret)
+	if subtle.ConstantTimeCompare([]byte(secretHash), []byte(account.ClientSecretHash)) != 1 {
+		return nil, ErrInvalidCredentials
+	}
+	if !account.IsActive {
+		return nil, ErrUserInactive
+	}
+
+	token, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	token = auth.ServiceTokenPrefix + token
+
+	record := &models.ServiceToken{
+		ServiceAccountID: account.ID,
+		TokenHash:        auth.HashToken(token),
+		ExpiresAt:        time.Now().Add(s.config.ServiceTokenTTL),
+	}
+	if err := s.serviceRepo.CreateToken(ctx, record); err != nil {
+		return nil, fmt.Errorf("failed to store service token: %w", err)
+	}
+
+	return &ServiceTokenResult{
+		AccessToken: token,
+		ExpiresAt:   record.ExpiresAt,
+		Account:     account,
+	}, nil
+}
+
+func servicePrincipal(account *models.ServiceAccount) *auth.Principal {
+	return &auth.Principal{
+		Type:             auth.PrincipalService,
+		ServiceAccountID: account.ID,
+		ServiceName:      account.Name,
+	}
 }
 
-func NewAuthService(authRepo repository.AuthRepository) AuthService {
-	return &authService{authRepo: authRepo}
+func (s *authService) Logout(ctx context.Context, token string, revokeFamily bool) error {
+	tokenHash, err := s.storageKey(token)
+	if err != nil {
+		return err
+	}
+
+	revoked, err := s.authRepo.RevokeToken(ctx, tokenHash)
+	if errors.Is(err, repository.ErrTokenNotFound) {
+		return ErrInvalidToken
+	}
+	if err != nil {
+		return err
+	}
+
+	if revokeFamily {
+		return s.authRepo.RevokeFamily(ctx, revoked.FamilyID)
+	}
+	return nil
+}
+
+func (s *authService) ListSessions(ctx context.Context, userID string) ([]*models.Session, error) {
+	if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.authRepo.ListSessions(ctx, userID)
 }
 
-func (s *authService) Login(ctx context.Context, username, password string) (string, interface{}, error) {
-	user, err := s.userRepo.GetByUsername(ctx, username)
+// RevokeSession отзывает все токены сессии, включая refresh токен, поэтому
+// продлить ее с отозванного устройства нельзя
+func (s *authService) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	return s.authRepo.RevokeSession(ctx, userID, sessionID)
+}
+
+func (s *authService) RevokeAllSessions(ctx context.Context, userID string) (int, error) {
+	if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+		return 0, err
+	}
+	return s.authRepo.RevokeUserSessions(ctx, userID)
+}
+
+// storageKey возвращает значение колонки auth_tokens.token для токена:
+// хэш самого токена для opaque и хэш jti для JWT (после проверки подписи)
+func (s *authService) storageKey(token string) (string, error) {
+	if s.jwt == nil || !auth.LooksLikeJWT(token) {
+		return auth.HashToken(token), nil
+	}
+
+	claims, err := s.jwt.Verify(token)
+	if err != nil || claims.ID == "" {
+		return "", ErrInvalidToken
+	}
+	return auth.HashToken(claims.ID), nil
+}
+
+// issueTokens выпускает access токен (opaque или JWT в зависимости от режима) и refresh токен
+// и сохраняет их хэши одной записью auth_tokens
+func (s *authService) issueTokens(ctx context.Context, user *models.User, session sessionInfo) (*LoginResult, error) {
+	var (
+		result    = &LoginResult{User: user}
+		tokenHash string
+		err       error
+	)
+
+	if s.config.Mode == config.AuthModeJWT {
+		tokenHash, err = s.issueJWT(user, result)
+	} else {
+		tokenHash, err = s.issueOpaqueToken(result)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := auth.GenerateOpaqueToken()
 	if err != nil {
-		return "", nil, err
+		return nil, err
 	}
+	refreshHash := auth.HashToken(refreshToken)
+	result.RefreshToken = refreshToken
+	result.RefreshExpiresAt = time.Now().Add(s.config.RefreshTokenTTL)
 
-	token, err := s.authRepo.CreateToken(ctx, "user-id")
-	return token, user, err
+	record := &models.AuthToken{
+		UserID:           user.ID,
+		FamilyID:         session.FamilyID,
+		TokenHash:        tokenHash,
+		TokenType:        result.TokenType,
+		ExpiresAt:        result.ExpiresAt,
+		RefreshTokenHash: &refreshHash,
+		RefreshExpiresAt: &result.RefreshExpiresAt,
+		ClientInfo:       session.ClientInfo,
+		UserAgent:        session.UserAgent,
+		IPAddress:        session.IPAddress,
+	}
+	if err := s.authRepo.CreateToken(ctx, record); err != nil {
+		return nil, fmt.Errorf("failed to store token: %w", err)
+	}
+
+	return result, nil
 }
 
-func (s *authService) ValidateToken(ctx context.Context, token string) (bool, interface{}, error) {
-	userID, err := s.authRepo.ValidateToken(ctx, token)
+// issueOpaqueToken генерирует случайный токен; возвращает хэш для хранения
+func (s *authService) issueOpaqueToken(result *LoginResult) (string, error) {
+	token, err := auth.GenerateOpaqueToken()
 	if err != nil {
-		return false, nil, err
+		return "", err
 	}
 
-	user, err := s.userRepo.GetByID(ctx, userID)
-	return true, user, err
+	result.AccessToken = token
+	result.TokenType = models.TokenTypeBearer
+	result.ExpiresAt = time.Now().Add(s.config.TokenTTL)
+
+	return auth.HashToken(token), nil
+}
+
+// issueJWT подписывает access токен с claims пользователя. Хранится хэш случайного jti,
+// чтобы токен можно было отозвать до истечения срока
+func (s *authService) issueJWT(user *models.User, result *LoginResult) (string, error) {
+	if s.jwt == nil {
+		return "", errors.New("jwt mode is enabled but jwt manager is not configured")
+	}
+
+	jti, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	claims := &auth.Claims{
+		UserID:           user.ID,
+		Roles:            user.Roles,
+		SubscriptionTier: user.SubscriptionTier,
+		Region:           user.Region,
+	}
+	claims.ID = jti
+
+	token, expiresAt, err := s.jwt.Issue(claims)
+	if err != nil {
+		return "", err
+	}
+
+	result.AccessToken = token
+	result.TokenType = models.TokenTypeJWT
+	result.ExpiresAt = expiresAt
+
+	return auth.HashToken(jti), nil
 }
 
-func (s *authService) Logout(ctx context.Context, token string) error {
-	return s.authRepo.RevokeToken(ctx, token)
+// isUserActive - пользователь может аутентифицироваться
+func isUserActive(user *models.User) bool {
+	return user.IsActive && user.Status == "active"
 }
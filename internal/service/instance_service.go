@@ -0,0 +1,36 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/haqury/user-service/internal/models"
+	"github.com/haqury/user-service/internal/repository"
+)
+
+// ErrInvalidInstanceLoad - инстанс сообщил некорректную нагрузку или емкость
+var ErrInvalidInstanceLoad = errors.New("instance load and capacity must not be negative")
+
+// InstanceService обслуживает запросы самих инстансов video-service
+type InstanceService interface {
+	// Heartbeat записывает текущую нагрузку инстанса; capacity 0 оставляет емкость прежней.
+	// Сообщенная нагрузка хранится отдельно от счетчика, который user-service увеличивает при
+	// назначении клиентов: heartbeat не должен стирать места, занятые между heartbeat'ами
+	Heartbeat(ctx context.Context, instanceID string, load, capacity int32) (*models.VideoServiceInstance, error)
+}
+
+type instanceService struct {
+	repo repository.VideoServiceInstanceRepository
+}
+
+func NewInstanceService(repo repository.VideoServiceInstanceRepository) InstanceService {
+	return &instanceService{repo: repo}
+}
+
+func (s *instanceService) Heartbeat(ctx context.Context, instanceID string, load, capacity int32) (*models.VideoServiceInstance, error) {
+	if load < 0 || capacity < 0 {
+		return nil, ErrInvalidInstanceLoad
+	}
+
+	return s.repo.Heartbeat(ctx, instanceID, load, capacity)
+}
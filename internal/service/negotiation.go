@@ -0,0 +1,147 @@
+package service
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/haqury/user-service/internal/config"
+	"github.com/haqury/user-service/internal/models"
+)
+
+var (
+	// ErrInvalidClientCapabilities - отрицательные лимиты в возможностях клиента
+	ErrInvalidClientCapabilities = errors.New("client max resolution and bandwidth must not be negative")
+	// ErrNoCompatibleInstance - подходящие инстансы есть, но ни один не поддерживает кодеки клиента
+	ErrNoCompatibleInstance = errors.New("no video service instance supports client codecs")
+)
+
+// qualityResolutions - высота кадра для названий качества из UserSettings.DefaultQuality.
+// Кроме них принимается явная высота вида "720p"; "auto" и пустое значение не ограничивают
+var qualityResolutions = map[string]int32{
+	"sd":  480,
+	"hd":  1080,
+	"qhd": 1440,
+	"2k":  1440,
+	"uhd": 2160,
+	"4k":  2160,
+}
+
+// resolutionBitrates - битрейт (кбит/с), достаточный для высоты кадра, по возрастанию высоты
+var resolutionBitrates = []struct {
+	resolution int32
+	bitrate    int32
+}{
+	{360, 800},
+	{480, 1500},
+	{720, 3000},
+	{1080, 5000},
+	{1440, 10000},
+	{2160, 20000},
+}
+
+// streamLimits - согласованные параметры потока
+type streamLimits struct {
+	MaxBitrate    int32
+	MaxResolution int32
+	Codec         string
+}
+
+// negotiateLimits вычисляет лимиты потока как минимум лимитов тарифа, инстанса, качества
+// из настроек пользователя и возможностей клиента. Нулевые лимиты не ограничивают
+func negotiateLimits(
+	user *models.User,
+	instance *models.VideoServiceInstance,
+	tier config.EntitlementConfig,
+	caps *models.ClientCapabilities,
+) streamLimits {
+	var clientResolution, clientBitrate int32
+	if caps != nil {
+		clientResolution, clientBitrate = caps.MaxResolution, caps.BandwidthKbps
+	}
+
+	userResolution := userQualityResolution(user)
+	return streamLimits{
+		MaxBitrate: minLimit(
+			tier.MaxBitrate, instance.MaxBitrate, bitrateForResolution(userResolution), clientBitrate,
+		),
+		MaxResolution: minLimit(tier.MaxResolution, instance.MaxResolution, userResolution, clientResolution),
+		Codec:         instance.Codec,
+	}
+}
+
+// validateCapabilities проверяет возможности клиента; nil - клиент их не сообщил
+func validateCapabilities(caps *models.ClientCapabilities) error {
+	if caps != nil && (caps.MaxResolution < 0 || caps.BandwidthKbps < 0) {
+		return ErrInvalidClientCapabilities
+	}
+	return nil
+}
+
+// supportsCodec - может ли клиент принять поток инстанса
+func supportsCodec(caps *models.ClientCapabilities, codec string) bool {
+	if caps == nil || len(caps.Codecs) == 0 {
+		return true
+	}
+	for _, supported := range caps.Codecs {
+		if strings.EqualFold(strings.TrimSpace(supported), codec) {
+			return true
+		}
+	}
+	return false
+}
+
+// compatible оставляет инстансы, поток которых может принять клиент
+func compatible(instances []*models.VideoServiceInstance, caps *models.ClientCapabilities) []*models.VideoServiceInstance {
+	rest := make([]*models.VideoServiceInstance, 0, len(instances))
+	for _, instance := range instances {
+		if supportsCodec(caps, instance.Codec) {
+			rest = append(rest, instance)
+		}
+	}
+	return rest
+}
+
+// userQualityResolution возвращает высоту кадра по качеству из настроек пользователя
+// (0 - без ограничения или качество не распознано)
+func userQualityResolution(user *models.User) int32 {
+	var settings models.UserSettings
+	if user.Settings == nil || user.Settings.Decode(&settings) != nil {
+		return 0
+	}
+
+	quality := strings.ToLower(strings.TrimSpace(settings.DefaultQuality))
+	if resolution, ok := qualityResolutions[quality]; ok {
+		return resolution
+	}
+	if height, ok := strings.CutSuffix(quality, "p"); ok {
+		if resolution, err := strconv.ParseInt(height, 10, 32); err == nil && resolution > 0 {
+			return int32(resolution)
+		}
+	}
+	return 0
+}
+
+// bitrateForResolution возвращает битрейт, достаточный для высоты кадра (0 - без ограничения)
+func bitrateForResolution(resolution int32) int32 {
+	if resolution <= 0 {
+		return 0
+	}
+	for _, step := range resolutionBitrates {
+		if resolution <= step.resolution {
+			return step.bitrate
+		}
+	}
+	return 0
+}
+
+// minLimit возвращает наименьший положительный лимит или 0, если ограничений нет
+func minLimit(limits ...int32) int32 {
+	var result int32
+	for _, limit := range limits {
+		if limit > 0 && (result == 0 || limit < result) {
+			result = limit
+		}
+	}
+	return result
+}
@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/haqury/user-service/internal/auth"
+	"github.com/haqury/user-service/internal/models"
+	"github.com/haqury/user-service/internal/repository"
+)
+
+var (
+	// ErrInvalidRoleName - имя роли не соответствует формату
+	ErrInvalidRoleName = errors.New("role name must be 1-64 characters: a-z, 0-9, '_' or '-'")
+
+	// ErrInvalidPermission - право не соответствует формату "ресурс:действие"
+	ErrInvalidPermission = errors.New("permission must look like resource:action, resource:* or *")
+
+	// ErrInvalidServiceAccountName - имя сервисного аккаунта не соответствует формату
+	ErrInvalidServiceAccountName = errors.New("service account name must be 1-64 characters: a-z, 0-9, '_' or '-'")
+)
+
+// roleNamePattern - формат имен ролей и сервисных аккаунтов (используются в политиках доступа)
+var roleNamePattern = regexp.MustCompile(`^[a-z0-9_-]{1,64}$`)
+
+// RBACService управляет ролями и проверяет права пользователей
+type RBACService interface {
+	CreateRole(ctx context.Context, role *models.Role) error
+	ListRoles(ctx context.Context) ([]*models.Role, error)
+	GrantPermission(ctx context.Context, role, permission string) error
+	RevokePermission(ctx context.Context, role, permission string) error
+
+	AssignRole(ctx context.Context, userID, role string) (*models.User, error)
+	RemoveRole(ctx context.Context, userID, role string) (*models.User, error)
+	// ValidateRoles проверяет, что все роли существуют, - для записи списка ролей
+	// пользователя целиком в обход AssignRole
+	ValidateRoles(ctx context.Context, roles []string) error
+
+	// HasPermission - у пользователя есть право через одну из его ролей.
+	// Неактивный пользователь прав не имеет
+	HasPermission(ctx context.Context, userID, permission string) (bool, error)
+}
+
+type rbacService struct {
+	roleRepo repository.RoleRepository
+	userRepo repository.UserRepository
+	cache    *permissionCache
+}
+
+// NewRBACService создает сервис RBAC. Права ролей кэшируются на cacheTTL
+// (0 - без кэширования); изменения через этот экземпляр сбрасывают кэш сразу,
+// изменения через другие реплики становятся видны не позже чем через cacheTTL
+func NewRBACService(roleRepo repository.RoleRepository, userRepo repository.UserRepository, cacheTTL time.Duration) RBACService {
+	return &rbacService{
+		roleRepo: roleRepo,
+		userRepo: userRepo,
+		cache:    newPermissionCache(cacheTTL),
+	}
+}
+
+func (s *rbacService) CreateRole(ctx context.Context, role *models.Role) error {
+	if !roleNamePattern.MatchString(role.Name) {
+		return ErrInvalidRoleName
+	}
+	for _, permission := range role.Permissions {
+		if !auth.ValidPermission(permission) {
+			return ErrInvalidPermission
+		}
+	}
+
+	if err := s.roleRepo.Create(ctx, role); err != nil {
+		return err
+	}
+	// Роль могла быть назначена пользователям до создания и закэширована без прав
+	s.cache.invalidate(role.Name)
+	return nil
+}
+
+func (s *rbacService) ListRoles(ctx context.Context) ([]*models.Role, error) {
+	return s.roleRepo.List(ctx)
+}
+
+func (s *rbacService) GrantPermission(ctx context.Context, role, permission string) error {
+	if !auth.ValidPermission(permission) {
+		return ErrInvalidPermission
+	}
+	if err := s.roleRepo.GrantPermission(ctx, role, permission); err != nil {
+		return err
+	}
+	s.cache.invalidate(role)
+	return nil
+}
+
+func (s *rbacService) RevokePermission(ctx context.Context, role, permission string) error {
+	if err := s.roleRepo.RevokePermission(ctx, role, permission); err != nil {
+		return err
+	}
+	s.cache.invalidate(role)
+	return nil
+}
+
+func (s *rbacService) AssignRole(ctx context.Context, userID, role string) (*models.User, error) {
+	if _, err := s.roleRepo.GetByName(ctx, role); err != nil {
+		return nil, err
+	}
+	return s.userRepo.AddRole(ctx, userID, role)
+}
+
+func (s *rbacService) RemoveRole(ctx context.Context, userID, role string) (*models.User, error) {
+	return s.userRepo.RemoveRole(ctx, userID, role)
+}
+
+func (s *rbacService) ValidateRoles(ctx context.Context, roles []string) error {
+	for _, role := range roles {
+		if _, err := s.roleRepo.GetByName(ctx, role); err != nil {
+			if errors.Is(err, repository.ErrRoleNotFound) {
+				return fmt.Errorf("%w: %s", err, role)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *rbacService) HasPermission(ctx context.Context, userID, permission string) (bool, error) {
+	if !auth.ValidPermission(permission) {
+		return false, ErrInvalidPermission
+	}
+
+	// Роли пользователя читаются из БД при каждой проверке: снятие роли
+	// или блокировка пользователя действуют сразу
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	if !isUserActive(user) {
+		return false, nil
+	}
+
+	permissions, err := s.rolePermissions(ctx, user.Roles)
+	if err != nil {
+		return false, err
+	}
+
+	for _, granted := range permissions {
+		if auth.PermissionMatches(granted, permission) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// rolePermissions возвращает права ролей, загружая из БД только отсутствующие в кэше
+func (s *rbacService) rolePermissions(ctx context.Context, roles []string) ([]string, error) {
+	permissions, missing := s.cache.get(roles)
+	if len(missing) == 0 {
+		return permissions, nil
+	}
+
+	loaded, err := s.roleRepo.PermissionsByRoles(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	for _, role := range missing {
+		s.cache.set(role, loaded[role])
+		permissions = append(permissions, loaded[role]...)
+	}
+	return permissions, nil
+}
+
+// permissionCache - кэш прав ролей с ограниченным временем жизни записей
+type permissionCache struct {
+	ttl     time.Duration
+	mu      sync.RWMutex
+	entries map[string]permissionCacheEntry
+}
+
+type permissionCacheEntry struct {
+	permissions []string
+	expiresAt   time.Time
+}
+
+func newPermissionCache(ttl time.Duration) *permissionCache {
+	return &permissionCache{
+		ttl:     ttl,
+		entries: make(map[string]permissionCacheEntry),
+	}
+}
+
+// get возвращает права закэшированных ролей и список ролей, которых нет в кэше
+func (c *permissionCache) get(roles []string) (permissions []string, missing []string) {
+	now := time.Now()
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, role := range roles {
+		entry, ok := c.entries[role]
+		if !ok || !now.Before(entry.expiresAt) {
+			missing = append(missing, role)
+			continue
+		}
+		permissions = append(permissions, entry.permissions...)
+	}
+	return permissions, missing
+}
+
+func (c *permissionCache) set(role string, permissions []string) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[role] = permissionCacheEntry{
+		permissions: permissions,
+		expiresAt:   time.Now().Add(c.ttl),
+	}
+}
+
+func (c *permissionCache) invalidate(role string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, role)
+}
@@ -4,140 +4,555 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
+	"strings"
+	"time"
 
+	"github.com/haqury/user-service/internal/balancer"
+	"github.com/haqury/user-service/internal/config"
 	"github.com/haqury/user-service/internal/models"
 	"github.com/haqury/user-service/internal/repository"
+	"github.com/haqury/user-service/pkg/streamticket"
 )
 
 type RoutingService interface {
 	// SelectVideoService выбирает оптимальный video-service для пользователя
 	SelectVideoService(ctx context.Context, user *models.User) (*models.VideoServiceInstance, error)
 
-	// AssignInstanceToClient назначает video-service инстанс клиенту
-	AssignInstanceToClient(ctx context.Context, clientID string, instanceID string) error
+	// AssignInstanceToClient назначает video-service инстанс клиенту пользователя
+	AssignInstanceToClient(ctx context.Context, userID, clientID, instanceID string) error
 
-	// GetStreamingConfigForClient получает конфигурацию стриминга для клиента
-	GetStreamingConfigForClient(ctx context.Context, userID, clientID string) (*models.StreamingConfig, error)
+	// GetStreamingConfigForClient получает конфигурацию стриминга для клиента с билетом
+	// на подключение к назначенному инстансу или, если подпись билетов не настроена,
+	// с новым API ключом (предыдущий ключ клиента отзывается). Инстанс должен поддерживать
+	// один из кодеков клиента, лимиты потока согласуются с тарифом, инстансом, настройками
+	// пользователя и возможностями клиента (caps может быть nil)
+	GetStreamingConfigForClient(
+		ctx context.Context,
+		userID, clientID string,
+		caps *models.ClientCapabilities,
+	) (*models.StreamingConfig, error)
+
+	// TouchStreamingSession продлевает сессию клиента (heartbeat); без heartbeat дольше
+	// client_ttl назначение снимает reaper
+	TouchStreamingSession(ctx context.Context, userID, clientID string) error
+
+	// ReleaseStreamingSession снимает назначение клиента и освобождает место на инстансе
+	ReleaseStreamingSession(ctx context.Context, userID, clientID string) error
+
+	// DrainInstance выводит инстанс из роутинга и переносит его клиентов на другие
+	// подходящие инстансы пачками по batchSize (0 - по умолчанию), занимая на них место
+	// так же, как при первом назначении. Клиенты, для которых не нашлось места, остаются
+	// на инстансе и будут перенаправлены при следующем запросе конфигурации
+	DrainInstance(ctx context.Context, instanceID string, batchSize int) (*DrainResult, error)
+
+	// SetInstanceState меняет состояние жизненного цикла инстанса. Для draining назначенные
+	// клиенты остаются на инстансе drainTimeout (0 - drain_timeout из конфигурации)
+	SetInstanceState(ctx context.Context, instanceID, state string, drainTimeout time.Duration) (*models.VideoServiceInstance, error)
 }
 
+// DrainResult - итог вывода инстанса из роутинга
+type DrainResult struct {
+	Migrated  int // перенесено клиентов
+	Remaining int // осталось на инстансе: нет места на других инстансах или пользователь неактивен
+}
+
+// Размер пачки клиентов при выводе инстанса
+const (
+	defaultDrainBatchSize = 100
+	maxDrainBatchSize     = 1000
+)
+
+var (
+	// ErrNoAvailableInstance - нет здорового инстанса со свободной емкостью для пользователя
+	ErrNoAvailableInstance = errors.New("no available video service instances")
+	// ErrInvalidDrainBatchSize - размер пачки при выводе инстанса вне допустимого диапазона
+	ErrInvalidDrainBatchSize = fmt.Errorf("drain batch size must be between 0 and %d", maxDrainBatchSize)
+	// ErrInvalidInstanceState - неизвестное состояние инстанса или отрицательный срок вывода
+	ErrInvalidInstanceState = errors.New("instance state must be one of active, draining, maintenance, retired; drain timeout must not be negative")
+)
+
 type routingService struct {
-	repos *repository.Repositories
+	repos    *repository.Repositories
+	apiKeys  APIKeyService
+	tickets  *streamticket.Signer // nil - клиенту выдается API ключ
+	balancer *balancer.Selector
+	regions  *balancer.RegionChains
+	config   config.RoutingConfig
 }
 
-func NewRoutingService(repos *repository.Repositories) RoutingService {
-	return &routingService{repos: repos}
+func NewRoutingService(
+	repos *repository.Repositories,
+	apiKeys APIKeyService,
+	tickets *streamticket.Signer,
+	selector *balancer.Selector,
+	regions *balancer.RegionChains,
+	cfg config.RoutingConfig,
+) RoutingService {
+	return &routingService{
+		repos:    repos,
+		apiKeys:  apiKeys,
+		tickets:  tickets,
+		balancer: selector,
+		regions:  regions,
+		config:   cfg,
+	}
 }
 
 // SelectVideoService выбирает оптимальный video-service инстанс для пользователя
 // Логика выбора:
-// 1. Фильтр по региону пользователя
+// 1. Регионы перебираются по цепочке региона пользователя (routing.regions), до первого
+// региона с подходящими инстансами
 // 2. Фильтр по тарифному плану
-// 3. Сортировка по приоритету (premium инстансы имеют выше приоритет)
-// 4. Выбор инстанса с наименьшей нагрузкой
+// 3. Из подходящих остаются инстансы с наибольшим приоритетом (premium инстансы имеют выше приоритет)
+// 4. Среди них выбирает стратегия балансировки, настроенная для тарифа или региона
 func (s *routingService) SelectVideoService(ctx context.Context, user *models.User) (*models.VideoServiceInstance, error) {
-	// Получаем инстансы по региону и тарифу
-	instances, err := s.repos.VideoServiceInstance.GetByRegionAndTier(ctx, user.Region, user.SubscriptionTier)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get instances: %w", err)
+	for _, region := range s.regions.Chain(user.Region) {
+		instances, err := s.repos.VideoServiceInstance.GetByRegionAndTier(ctx, region, user.SubscriptionTier)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get instances in region %s: %w", region, err)
+		}
+		if len(instances) == 0 {
+			continue
+		}
+
+		strategy := s.balancer.For(region, user.SubscriptionTier)
+		return strategy.Select(user.ID, topPriority(instances)), nil
 	}
 
-	if len(instances) == 0 {
-		// Если нет подходящих инстансов в регионе пользователя, пробуем default регион
-		instances, err = s.repos.VideoServiceInstance.GetByRegionAndTier(ctx, "default", user.SubscriptionTier)
+	return nil, ErrNoAvailableInstance
+}
+
+// reserveVideoService выбирает инстанс так же, как SelectVideoService, среди инстансов
+// с кодеком клиента и занимает на нем место. Если выбранный инстанс успели заполнить
+// параллельные клиенты, выбор повторяется среди оставшихся кандидатов региона, затем
+// в следующих регионах цепочки. Возвращает также глубину fallback региона инстанса
+// (0 - регион пользователя)
+func (s *routingService) reserveVideoService(
+	ctx context.Context,
+	user *models.User,
+	caps *models.ClientCapabilities,
+) (*models.VideoServiceInstance, int, error) {
+	incompatible := false
+	for depth, region := range s.regions.Chain(user.Region) {
+		found, err := s.repos.VideoServiceInstance.GetByRegionAndTier(ctx, region, user.SubscriptionTier)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get default instances: %w", err)
+			return nil, 0, fmt.Errorf("failed to get instances in region %s: %w", region, err)
+		}
+		instances := compatible(found, caps)
+		if len(instances) < len(found) {
+			incompatible = true
 		}
-	}
 
-	if len(instances) == 0 {
-		return nil, errors.New("no available video service instances")
+		strategy := s.balancer.For(region, user.SubscriptionTier)
+		for len(instances) > 0 {
+			picked := strategy.Select(user.ID, topPriority(instances))
+
+			instance, err := s.repos.VideoServiceInstance.ReserveSlot(ctx, picked.ID)
+			if err == nil {
+				return instance, depth, nil
+			}
+			if !errors.Is(err, repository.ErrInstanceFull) {
+				return nil, 0, fmt.Errorf("failed to reserve instance: %w", err)
+			}
+
+			instances = without(instances, picked.ID)
+		}
 	}
 
-	// Возвращаем первый инстанс (уже отсортирован по priority DESC, current_load ASC)
-	return instances[0], nil
+	if incompatible {
+		return nil, 0, fmt.Errorf("%w: %s", ErrNoCompatibleInstance, strings.Join(caps.Codecs, ", "))
+	}
+	return nil, 0, ErrNoAvailableInstance
 }
 
-// AssignInstanceToClient назначает video-service инстанс клиенту
-func (s *routingService) AssignInstanceToClient(ctx context.Context, clientID string, instanceID string) error {
-	return s.repos.UserClient.AssignInstance(ctx, clientID, instanceID)
+// AssignInstanceToClient назначает video-service инстанс клиенту пользователя
+func (s *routingService) AssignInstanceToClient(ctx context.Context, userID, clientID, instanceID string) error {
+	return s.repos.UserClient.AssignInstance(ctx, userID, clientID, instanceID, nil)
 }
 
 // GetStreamingConfigForClient получает конфигурацию стриминга для клиента
-func (s *routingService) GetStreamingConfigForClient(ctx context.Context, userID, clientID string) (*models.StreamingConfig, error) {
+func (s *routingService) GetStreamingConfigForClient(
+	ctx context.Context,
+	userID, clientID string,
+	caps *models.ClientCapabilities,
+) (*models.StreamingConfig, error) {
+	if err := validateCapabilities(caps); err != nil {
+		return nil, err
+	}
+
+	user, err := s.getUserByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
 	// Проверяем, есть ли уже назначенный инстанс для этого клиента
 	userClient, err := s.repos.UserClient.GetByClientID(ctx, clientID)
+	if errors.Is(err, repository.ErrUserClientNotFound) {
+		userClient = nil
+	} else if err != nil {
+		return nil, err
+	} else if userClient.UserID != userID {
+		// client_id другого пользователя: билет на его инстанс выдавать нельзя,
+		// существование чужого клиента не раскрывается
+		return nil, repository.ErrUserClientNotFound
+	}
 	var instance *models.VideoServiceInstance
+	var depth int
 
-	if err != nil || userClient == nil || userClient.AssignedInstanceID == nil {
-		// Если клиент еще не зарегистрирован или нет назначенного инстанса,
-		// нужно получить пользователя и выбрать инстанс
-		user, err := s.getUserByID(ctx, userID)
+	if userClient != nil && userClient.AssignedInstanceID != nil {
+		// Назначение сохраняется, пока инстанс может обслуживать клиента
+		instance, depth, err = s.stickyInstance(ctx, user, userClient, caps)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get user: %w", err)
+			return nil, err
 		}
+	}
 
-		// Выбираем оптимальный инстанс
-		instance, err = s.SelectVideoService(ctx, user)
+	if instance == nil {
+		// Если клиент еще не зарегистрирован, нет назначенного инстанса или он недоступен,
+		// выбираем оптимальный инстанс и занимаем на нем место
+		instance, depth, err = s.reserveVideoService(ctx, user, caps)
 		if err != nil {
 			return nil, fmt.Errorf("failed to select video service: %w", err)
 		}
+		if depth > 0 {
+			log.Printf("Client %s of user %s (region %q) assigned cross-region to instance %s in %s (fallback depth %d)",
+				clientID, userID, user.Region, instance.Name, instance.Region, depth)
+		}
 
-		// Если клиент существует, обновляем его назначение
-		if userClient != nil {
-			err = s.repos.UserClient.AssignInstance(ctx, clientID, instance.ID)
-			if err != nil {
-				return nil, fmt.Errorf("failed to assign instance: %w", err)
+		if err := s.saveAssignment(ctx, userClient, userID, clientID, instance.ID, caps); err != nil {
+			// Назначение не записано - место на инстансе никто не займет
+			if releaseErr := s.repos.VideoServiceInstance.ReleaseSlot(ctx, instance.ID); releaseErr != nil {
+				log.Printf("Failed to release slot on instance %s: %v", instance.ID, releaseErr)
 			}
-		} else {
-			// Создаем новую запись клиента
-			userClient = &models.UserClient{
-				UserID:             userID,
-				ClientID:           clientID,
-				AssignedInstanceID: &instance.ID,
-				IsActive:           true,
+			if !errors.Is(err, repository.ErrUserClientAssigned) && !errors.Is(err, repository.ErrUserClientAlreadyExists) {
+				return nil, err
 			}
-			err = s.repos.UserClient.Create(ctx, userClient)
+
+			// Параллельный запрос того же клиента успел назначить инстанс: отдаем его назначение
+			instance, depth, err = s.concurrentAssignment(ctx, user, clientID)
 			if err != nil {
-				return nil, fmt.Errorf("failed to create user client: %w", err)
+				return nil, err
 			}
 		}
-	} else {
-		// Получаем инстанс по ID
-		instance, err = s.repos.VideoServiceInstance.GetByID(ctx, *userClient.AssignedInstanceID)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get instance: %w", err)
-		}
 	}
 
-	// Формируем конфигурацию стриминга
+	// Формируем конфигурацию стриминга с согласованными лимитами
+	limits := negotiateLimits(user, instance, s.config.Entitlements[user.SubscriptionTier], caps)
 	config := &models.StreamingConfig{
 		ServerURL:      instance.ServerURL,
 		ServerPort:     instance.ServerPort,
 		UseSSL:         instance.UseSSL,
-		APIKey:         s.generateAPIKey(userID, clientID), // TODO: implement API key generation
 		StreamEndpoint: instance.StreamEndpoint,
-		MaxBitrate:     instance.MaxBitrate,
-		MaxResolution:  instance.MaxResolution,
-		Codec:          instance.Codec,
+		MaxBitrate:     limits.MaxBitrate,
+		MaxResolution:  limits.MaxResolution,
+		Codec:          limits.Codec,
+		Region:         instance.Region,
+		FallbackDepth:  int32(depth),
+	}
+
+	if s.tickets != nil {
+		// Билет привязан к назначенному инстансу и несет лимиты потока,
+		// поэтому video-service проверяет его без обращения к user-service
+		ticket, expiresAt, err := s.tickets.Sign(&streamticket.Claims{
+			UserID:        userID,
+			ClientID:      clientID,
+			InstanceID:    instance.ID,
+			MaxBitrate:    limits.MaxBitrate,
+			MaxResolution: limits.MaxResolution,
+			Codec:         limits.Codec,
+		})
+		if err != nil {
+			return nil, err
+		}
+		config.StreamTicket = ticket
+		config.TicketExpiresAt = expiresAt.Unix()
+	} else {
+		// Ключ для подключения к video-service; предыдущий ключ клиента отзывается
+		config.APIKey, err = s.apiKeys.IssueClientKey(ctx, userID, clientID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to issue api key: %w", err)
+		}
 	}
 
 	return config, nil
 }
 
+func (s *routingService) TouchStreamingSession(ctx context.Context, userID, clientID string) error {
+	return s.repos.UserClient.UpdateLastSeen(ctx, userID, clientID)
+}
+
+func (s *routingService) ReleaseStreamingSession(ctx context.Context, userID, clientID string) error {
+	_, err := s.repos.UserClient.Release(ctx, userID, clientID, models.ReleaseReasonClient)
+	return err
+}
+
+func (s *routingService) DrainInstance(ctx context.Context, instanceID string, batchSize int) (*DrainResult, error) {
+	if batchSize < 0 || batchSize > maxDrainBatchSize {
+		return nil, ErrInvalidDrainBatchSize
+	}
+	if batchSize == 0 {
+		batchSize = defaultDrainBatchSize
+	}
+
+	// Выводимый инстанс не выбирается для новых клиентов и не принимает резервирования,
+	// поэтому клиенты не вернутся на него во время переноса. Срок вывода истекает сразу:
+	// оставшихся клиентов перенаправит следующий запрос конфигурации или reaper
+	instance, err := s.repos.VideoServiceInstance.GetByID(ctx, instanceID)
+	if err != nil {
+		return nil, err
+	}
+	if instance.State != models.InstanceStateMaintenance {
+		now := time.Now()
+		if _, err := s.repos.VideoServiceInstance.SetState(ctx, instanceID, models.InstanceStateDraining, &now); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &DrainResult{}
+	users := make(map[string]*models.User)
+	afterID := ""
+	for {
+		clients, err := s.repos.UserClient.ListByInstance(ctx, instanceID, afterID, batchSize)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, client := range clients {
+			if err := s.migrateClient(ctx, client, instanceID, users, result); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(clients) < batchSize {
+			break
+		}
+		afterID = clients[len(clients)-1].ID
+	}
+
+	log.Printf("Instance %s drained: %d clients migrated, %d remaining", instanceID, result.Migrated, result.Remaining)
+	return result, nil
+}
+
+// migrateClient переносит клиента с инстанса from и учитывает итог в result. Клиент,
+// назначение которого параллельно изменилось, не учитывается
+func (s *routingService) migrateClient(
+	ctx context.Context,
+	client *models.UserClient,
+	from string,
+	users map[string]*models.User,
+	result *DrainResult,
+) error {
+	user, ok := users[client.UserID]
+	if !ok {
+		var err error
+		user, err = s.getUserByID(ctx, client.UserID)
+		if errors.Is(err, repository.ErrUserNotFound) || errors.Is(err, ErrUserInactive) {
+			// Клиент удаленного или отключенного пользователя остается до истечения срока вывода
+			log.Printf("Drain %s: client %s: %v", from, client.ClientID, err)
+		} else if err != nil {
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+		users[client.UserID] = user
+	}
+	if user == nil {
+		result.Remaining++
+		return nil
+	}
+
+	// Клиент переносится только на инстанс с кодеком, который он сообщил при назначении
+	caps := &models.ClientCapabilities{Codecs: client.Codecs}
+	instance, _, err := s.reserveVideoService(ctx, user, caps)
+	if errors.Is(err, ErrNoAvailableInstance) || errors.Is(err, ErrNoCompatibleInstance) {
+		result.Remaining++
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	err = s.repos.UserClient.MoveAssignment(ctx, client.ClientID, from, instance.ID)
+	if err == nil {
+		result.Migrated++
+		return nil
+	}
+
+	// Клиент завершил сессию или переназначен параллельно - занятое место не нужно
+	if releaseErr := s.repos.VideoServiceInstance.ReleaseSlot(ctx, instance.ID); releaseErr != nil {
+		log.Printf("Failed to release slot on instance %s: %v", instance.ID, releaseErr)
+	}
+	if errors.Is(err, repository.ErrStreamingSessionNotFound) {
+		return nil
+	}
+	return err
+}
+
+func (s *routingService) SetInstanceState(ctx context.Context, instanceID, state string, drainTimeout time.Duration) (*models.VideoServiceInstance, error) {
+	if _, ok := models.InstanceStateTransitions[state]; !ok || drainTimeout < 0 {
+		return nil, ErrInvalidInstanceState
+	}
+
+	var deadline *time.Time
+	if state == models.InstanceStateDraining {
+		if drainTimeout == 0 {
+			drainTimeout = s.config.Sessions.DrainTimeout
+		}
+		at := time.Now().Add(drainTimeout)
+		deadline = &at
+	}
+
+	instance, err := s.repos.VideoServiceInstance.SetState(ctx, instanceID, state, deadline)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Instance %s (%s) is now %s", instance.Name, instance.ID, instance.State)
+	return instance, nil
+}
+
+// stickyInstance возвращает назначенный клиенту инстанс и глубину его региона в цепочке
+// пользователя, если инстанс еще может обслуживать клиента, поддерживает его кодеки и
+// находится в регионе из цепочки. Иначе назначение снимается с освобождением места,
+// и возвращается nil - клиенту нужно выбрать новый инстанс
+func (s *routingService) stickyInstance(
+	ctx context.Context,
+	user *models.User,
+	userClient *models.UserClient,
+	caps *models.ClientCapabilities,
+) (*models.VideoServiceInstance, int, error) {
+	instance, err := s.repos.VideoServiceInstance.GetByID(ctx, *userClient.AssignedInstanceID)
+	if err != nil && !errors.Is(err, repository.ErrVideoServiceInstanceNotFound) {
+		return nil, 0, fmt.Errorf("failed to get instance: %w", err)
+	}
+
+	depth := -1
+	if err == nil {
+		depth = s.regions.Depth(user.Region, instance.Region)
+	}
+	if err == nil && servable(instance) && supportsCodec(caps, instance.Codec) && depth >= 0 {
+		return instance, depth, nil
+	}
+
+	reason := models.ReleaseReasonFailover
+	switch {
+	case err != nil:
+	case !instance.KeepsClients(time.Now()):
+		reason = models.ReleaseReasonDrained
+	case !servable(instance):
+	case !supportsCodec(caps, instance.Codec):
+		reason = models.ReleaseReasonIncompatible
+	default:
+		// Регион пользователя сменился или инстанс перенесен: назначение
+		// вне цепочки регионов не сохраняется
+		reason = models.ReleaseReasonRegion
+	}
+	_, err = s.repos.UserClient.Release(ctx, userClient.UserID, userClient.ClientID, reason)
+	if err != nil && !errors.Is(err, repository.ErrStreamingSessionNotFound) {
+		return nil, 0, fmt.Errorf("failed to release unavailable instance: %w", err)
+	}
+	log.Printf("Client %s: assigned instance %s is unavailable (%s), rerouting",
+		userClient.ClientID, *userClient.AssignedInstanceID, reason)
+
+	return nil, 0, nil
+}
+
+// servable - можно ли оставлять на инстансе уже назначенных клиентов. В отличие от выбора
+// нового инстанса, degraded, заполненный и выводимый (до срока) инстанс подходят:
+// клиент уже занимает на нем место
+func servable(instance *models.VideoServiceInstance) bool {
+	return instance.IsActive &&
+		instance.HealthStatus != models.HealthStatusUnhealthy &&
+		instance.KeepsClients(time.Now())
+}
+
+// saveAssignment записывает назначение инстанса существующему клиенту или создает клиента.
+// Кодеки клиента сохраняются для переноса при выводе инстанса
+func (s *routingService) saveAssignment(
+	ctx context.Context,
+	userClient *models.UserClient,
+	userID, clientID, instanceID string,
+	caps *models.ClientCapabilities,
+) error {
+	var codecs []string
+	if caps != nil {
+		codecs = caps.Codecs
+	}
+
+	if userClient != nil {
+		if err := s.repos.UserClient.AssignInstance(ctx, userID, clientID, instanceID, codecs); err != nil {
+			return fmt.Errorf("failed to assign instance: %w", err)
+		}
+		return nil
+	}
+
+	userClient = &models.UserClient{
+		UserID:             userID,
+		ClientID:           clientID,
+		AssignedInstanceID: &instanceID,
+		Codecs:             codecs,
+		IsActive:           true,
+	}
+	return s.repos.UserClient.Create(ctx, userClient)
+}
+
+// concurrentAssignment перечитывает назначение клиента, записанное параллельным запросом
+func (s *routingService) concurrentAssignment(
+	ctx context.Context,
+	user *models.User,
+	clientID string,
+) (*models.VideoServiceInstance, int, error) {
+	userClient, err := s.repos.UserClient.GetByClientID(ctx, clientID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if userClient.UserID != user.ID {
+		// client_id успел занять другой пользователь
+		return nil, 0, repository.ErrUserClientNotFound
+	}
+	if userClient.AssignedInstanceID == nil {
+		// Назначение параллельного запроса уже снято; клиент может запросить конфигурацию снова
+		return nil, 0, fmt.Errorf("%w: client %s was released concurrently", ErrNoAvailableInstance, clientID)
+	}
+
+	instance, err := s.repos.VideoServiceInstance.GetByID(ctx, *userClient.AssignedInstanceID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get instance: %w", err)
+	}
+	return instance, max(s.regions.Depth(user.Region, instance.Region), 0), nil
+}
+
 // getUserByID - вспомогательная функция для получения пользователя
-// TODO: implement properly через UserRepository
 func (s *routingService) getUserByID(ctx context.Context, userID string) (*models.User, error) {
-	// Временная заглушка - нужно будет реализовать через UserRepository
-	return &models.User{
-		ID:               userID,
-		IsActive:         true,
-		SubscriptionTier: "free",
-		Region:           "default",
-	}, nil
-}
-
-// generateAPIKey генерирует API ключ для клиента
-// TODO: implement proper API key generation with JWT or similar
-func (s *routingService) generateAPIKey(userID, clientID string) string {
-	return fmt.Sprintf("api_key_%s_%s", userID, clientID)
+	user, err := s.repos.User.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.IsActive {
+		return nil, fmt.Errorf("%w: %s", ErrUserInactive, userID)
+	}
+
+	return user, nil
+}
+
+// topPriority возвращает инстансы с наибольшим приоритетом из списка,
+// отсортированного по priority DESC
+func topPriority(instances []*models.VideoServiceInstance) []*models.VideoServiceInstance {
+	for i, instance := range instances {
+		if instance.Priority != instances[0].Priority {
+			return instances[:i]
+		}
+	}
+	return instances
+}
+
+// without возвращает копию списка без инстанса с указанным ID
+func without(instances []*models.VideoServiceInstance, id string) []*models.VideoServiceInstance {
+	rest := make([]*models.VideoServiceInstance, 0, len(instances))
+	for _, instance := range instances {
+		if instance.ID != id {
+			rest = append(rest, instance)
+		}
+	}
+	return rest
 }
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/haqury/user-service/internal/balancer"
+	"github.com/haqury/user-service/internal/config"
+	"github.com/haqury/user-service/internal/models"
+	"github.com/haqury/user-service/internal/repository"
+)
+
+// fakeInstanceRepository - in-memory VideoServiceInstanceRepository. Реализованы методы,
+// нужные выбору инстанса; вызов остальных - ошибка теста (nil интерфейс)
+type fakeInstanceRepository struct {
+	repository.VideoServiceInstanceRepository
+	instances []*models.VideoServiceInstance
+}
+
+// GetByRegionAndTier повторяет фильтр и порядок SQL запроса репозитория
+func (r *fakeInstanceRepository) GetByRegionAndTier(_ context.Context, region, tier string) ([]*models.VideoServiceInstance, error) {
+	var found []*models.VideoServiceInstance
+	for _, instance := range r.instances {
+		if instance.IsActive &&
+			instance.State == models.InstanceStateActive &&
+			instance.HealthStatus == models.HealthStatusHealthy &&
+			instance.Region == region &&
+			slices.Contains(instance.AllowedTiers, tier) &&
+			instance.CurrentLoad < instance.MaxCapacity {
+			found = append(found, instance)
+		}
+	}
+	slices.SortStableFunc(found, func(a, b *models.VideoServiceInstance) int {
+		if c := cmp.Compare(b.Priority, a.Priority); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.CurrentLoad, b.CurrentLoad)
+	})
+	return found, nil
+}
+
+// lastRand всегда выбирает последнее значение диапазона
+type lastRand struct{}
+
+func (lastRand) IntN(n int) int {
+	return n - 1
+}
+
+func testInstance(id, region string, priority, load int32) *models.VideoServiceInstance {
+	return &models.VideoServiceInstance{
+		ID:           id,
+		Region:       region,
+		Priority:     priority,
+		CurrentLoad:  load,
+		MaxCapacity:  10,
+		AllowedTiers: []string{"free", "premium"},
+		HealthStatus: models.HealthStatusHealthy,
+		State:        models.InstanceStateActive,
+		IsActive:     true,
+	}
+}
+
+func TestTopPriority(t *testing.T) {
+	tests := []struct {
+		name      string
+		instances []*models.VideoServiceInstance
+		want      []string
+	}{
+		{
+			name:      "single instance",
+			instances: []*models.VideoServiceInstance{testInstance("a", "eu", 5, 0)},
+			want:      []string{"a"},
+		},
+		{
+			name: "same priority",
+			instances: []*models.VideoServiceInstance{
+				testInstance("a", "eu", 5, 0),
+				testInstance("b", "eu", 5, 3),
+			},
+			want: []string{"a", "b"},
+		},
+		{
+			name: "lower priority dropped",
+			instances: []*models.VideoServiceInstance{
+				testInstance("a", "eu", 10, 7),
+				testInstance("b", "eu", 10, 8),
+				testInstance("c", "eu", 5, 0),
+				testInstance("d", "eu", 1, 0),
+			},
+			want: []string{"a", "b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, instance := range topPriority(tt.instances) {
+				got = append(got, instance.ID)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("topPriority() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectVideoService(t *testing.T) {
+	// Простаивающие инстансы с наибольшим приоритетом, которые роутинг не должен выбирать
+	inactive := testInstance("us-inactive", "us", 20, 0)
+	inactive.IsActive = false
+	unhealthy := testInstance("us-unhealthy", "us", 20, 0)
+	unhealthy.HealthStatus = models.HealthStatusUnhealthy
+	draining := testInstance("us-draining", "us", 20, 0)
+	draining.State = models.InstanceStateDraining
+
+	// С lastRand стратегии выбирают разные инстансы eu (нагрузка 1, 2, 5):
+	// least_load - eu-a, power_of_two - eu-b, weighted_random - eu-c
+	repo := &fakeInstanceRepository{instances: []*models.VideoServiceInstance{
+		inactive, unhealthy, draining,
+		testInstance("eu-a", "eu", 10, 1),
+		testInstance("eu-b", "eu", 10, 2),
+		testInstance("eu-c", "eu", 10, 5),
+		testInstance("us-a", "us", 5, 3),
+		testInstance("us-b", "us", 5, 1),
+		testInstance("us-c", "us", 5, 6),
+		testInstance("us-low", "us", 1, 0),
+		testInstance("ap-full", "ap", 10, 10),
+		testInstance("ap-low", "ap", 1, 5),
+		testInstance("default-a", config.DefaultRegion, 1, 0),
+	}}
+
+	selector, err := balancer.NewSelector(config.BalancerConfig{
+		Default: config.BalancerLeastLoad,
+		Regions: map[string]string{"eu": config.BalancerPowerOfTwo},
+		Tiers:   map[string]string{"premium": config.BalancerWeightedRandom},
+	}, lastRand{})
+	if err != nil {
+		t.Fatalf("NewSelector() error = %v", err)
+	}
+	regions, err := balancer.NewRegionChains(config.RegionConfig{})
+	if err != nil {
+		t.Fatalf("NewRegionChains() error = %v", err)
+	}
+	routing := NewRoutingService(
+		&repository.Repositories{VideoServiceInstance: repo},
+		nil, nil, selector, regions, config.RoutingConfig{},
+	)
+
+	tests := []struct {
+		name    string
+		region  string
+		tier    string
+		want    string
+		wantErr error
+	}{
+		{name: "default strategy within top priority", region: "us", tier: "free", want: "us-b"},
+		{name: "region strategy", region: "eu", tier: "free", want: "eu-b"},
+		{name: "tier strategy overrides region", region: "eu", tier: "premium", want: "eu-c"},
+		{name: "tier strategy without region override", region: "us", tier: "premium", want: "us-c"},
+		{name: "full top priority instance skipped", region: "ap", tier: "free", want: "ap-low"},
+		{name: "region without instances falls back to default", region: "asia", tier: "free", want: "default-a"},
+		{name: "tier without instances", region: "eu", tier: "enterprise", wantErr: ErrNoAvailableInstance},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := &models.User{ID: "user-1", Region: tt.region, SubscriptionTier: tt.tier}
+
+			got, err := routing.SelectVideoService(context.Background(), user)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("SelectVideoService() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SelectVideoService() error = %v", err)
+			}
+			if got.ID != tt.want {
+				t.Errorf("SelectVideoService() = %s, want %s", got.ID, tt.want)
+			}
+		})
+	}
+}
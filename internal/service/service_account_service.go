@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/haqury/user-service/internal/auth"
+	"github.com/haqury/user-service/internal/models"
+	"github.com/haqury/user-service/internal/repository"
+)
+
+// clientIDBytes - длина случайного client_id (128 бит)
+const clientIDBytes = 16
+
+// ServiceAccountService управляет учетными записями внутренних сервисов
+type ServiceAccountService interface {
+	// Create создает аккаунт и возвращает client_secret; секрет не хранится
+	// и больше не может быть получен
+	Create(ctx context.Context, account *models.ServiceAccount) (string, error)
+	List(ctx context.Context) ([]*models.ServiceAccount, error)
+	Delete(ctx context.Context, id string) error
+}
+
+type serviceAccountService struct {
+	repo repository.ServiceAccountRepository
+}
+
+func NewServiceAccountService(repo repository.ServiceAccountRepository) ServiceAccountService {
+	return &serviceAccountService{repo: repo}
+}
+
+func (s *serviceAccountService) Create(ctx context.Context, account *models.ServiceAccount) (string, error) {
+	if !roleNamePattern.MatchString(account.Name) {
+		return "", ErrInvalidServiceAccountName
+	}
+
+	clientID := make([]byte, clientIDBytes)
+	if _, err := rand.Read(clientID); err != nil {
+		return "", fmt.Errorf("failed to generate client id: %w", err)
+	}
+	secret, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	account.ClientID = hex.EncodeToString(clientID)
+	account.ClientSecretHash = auth.HashToken(secret)
+	if err := s.repo.Create(ctx, account); err != nil {
+		return "", err
+	}
+
+	return secret, nil
+}
+
+func (s *serviceAccountService) List(ctx context.Context) ([]*models.ServiceAccount, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *serviceAccountService) Delete(ctx context.Context, id string) error {
+	return s.repo.Delete(ctx, id)
+}
@@ -1,17 +1,125 @@
 package service
 
-import "github.com/haqury/user-service/internal/repository"
+import (
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/haqury/user-service/internal/auth"
+	"github.com/haqury/user-service/internal/balancer"
+	"github.com/haqury/user-service/internal/config"
+	"github.com/haqury/user-service/internal/repository"
+	"github.com/haqury/user-service/internal/throttle"
+	"github.com/haqury/user-service/pkg/streamticket"
+)
 
 type Services struct {
 	User    UserService
 	Auth    AuthService
 	Routing RoutingService
+	RBAC    RBACService
+
+	ServiceAccounts ServiceAccountService
+	APIKeys         APIKeyService
+	Instances       InstanceService
+
+	// Keys - связка ключей подписи JWT (nil в режиме opaque)
+	Keys *auth.KeyRing
 }
 
-func New(repos *repository.Repositories) *Services {
+// New собирает сервисы. redisClient нужен, только если какая-либо подсистема
+// настроена на хранилище redis, иначе может быть nil
+func New(repos *repository.Repositories, c *config.Config, redisClient *redis.Client) (*Services, error) {
+	var keys *auth.KeyRing
+	var jwtManager *auth.JWTManager
+	if c.Auth.Mode == config.AuthModeJWT {
+		ringKeys, err := auth.RingKeysFromConfig(c.JWT)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load jwt keys: %w", err)
+		}
+
+		overlap := c.JWT.RotationOverlap
+		if overlap < c.JWT.Expiration {
+			overlap = c.JWT.Expiration
+		}
+		keys, err = auth.NewKeyRing(ringKeys, overlap)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create key ring: %w", err)
+		}
+		jwtManager = auth.NewJWTManager(keys, c.JWT.Issuer, c.JWT.Audience, c.JWT.Expiration)
+	}
+
+	hasher, err := auth.NewPasswordHasher(c.Password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create password hasher: %w", err)
+	}
+
+	throttler, err := newLoginThrottler(c.Auth.Throttle, redisClient)
+	if err != nil {
+		return nil, err
+	}
+
+	apiKeys := NewAPIKeyService(repos.APIKey, repos.User, c.Auth.APIKeys)
+
+	tickets, err := newStreamTicketSigner(c.StreamTicket)
+	if err != nil {
+		return nil, err
+	}
+
+	selector, err := balancer.NewSelector(c.Routing.Balancer, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create balancer: %w", err)
+	}
+
+	regions, err := balancer.NewRegionChains(c.Routing.Regions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create region chains: %w", err)
+	}
+
 	return &Services{
-		User:    NewUserService(repos.User, repos.UserClient),
-		Auth:    NewAuthService(repos.Auth),
-		Routing: NewRoutingService(repos),
+		User:    NewUserService(repos.User, repos.UserClient, hasher),
+		Auth:    NewAuthService(repos.Auth, repos.User, repos.ServiceAccount, c.Auth, jwtManager, hasher, throttler),
+		Routing: NewRoutingService(repos, apiKeys, tickets, selector, regions, c.Routing),
+		RBAC:    NewRBACService(repos.Role, repos.User, c.RBAC.CacheTTL),
+
+		ServiceAccounts: NewServiceAccountService(repos.ServiceAccount),
+		APIKeys:         apiKeys,
+		Instances:       NewInstanceService(repos.VideoServiceInstance),
+		Keys:            keys,
+	}, nil
+}
+
+// newLoginThrottler создает ограничитель попыток входа (nil, если отключен)
+func newLoginThrottler(c config.LoginThrottleConfig, redisClient *redis.Client) (*throttle.LoginThrottler, error) {
+	if !c.Enabled {
+		return nil, nil
+	}
+
+	var store throttle.Store
+	switch c.Store {
+	case config.ThrottleStoreMemory, "":
+		store = throttle.NewMemoryStore()
+	case config.ThrottleStoreRedis:
+		if redisClient == nil {
+			return nil, fmt.Errorf("login throttle store is redis but redis client is not configured")
+		}
+		store = throttle.NewRedisStore(redisClient)
+	default:
+		return nil, fmt.Errorf("unsupported login throttle store: %s", c.Store)
+	}
+
+	return throttle.NewLoginThrottler(store, c), nil
+}
+
+// newStreamTicketSigner создает подпись билетов стриминга (nil, если секрет не задан)
+func newStreamTicketSigner(c config.StreamTicketConfig) (*streamticket.Signer, error) {
+	if c.Secret == "" {
+		return nil, nil
+	}
+
+	signer, err := streamticket.NewSigner(streamticket.Key{ID: c.KeyID, Secret: []byte(c.Secret)}, c.TTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stream ticket signer: %w", err)
 	}
+	return signer, nil
 }
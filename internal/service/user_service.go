@@ -2,20 +2,32 @@ package service
 
 import (
 	"context"
+	"errors"
 
+	"github.com/haqury/user-service/internal/auth"
+	"github.com/haqury/user-service/internal/models"
 	"github.com/haqury/user-service/internal/repository"
 )
 
 type UserService interface {
-	GetUser(ctx context.Context, id string) (interface{}, error)
-	GetUserByUsername(ctx context.Context, username string) (interface{}, error)
+	GetUser(ctx context.Context, id string) (*models.User, error)
+	GetUserByUsername(ctx context.Context, username string) (*models.User, error)
 	GetUserByClientID(ctx context.Context, clientID string) (*UserByClientIDResponse, error)
-	CreateUser(ctx context.Context, user interface{}) error
-	UpdateUser(ctx context.Context, id string, user interface{}) error
+	CreateUser(ctx context.Context, user *models.User, password string) error
+	UpdateUser(ctx context.Context, id string, user *models.User) error
 	DeleteUser(ctx context.Context, id string) error
-	ListUsers(ctx context.Context, page, limit int, filter string) ([]interface{}, int, error)
+	ListUsers(ctx context.Context, page, limit int, filter string) ([]*models.User, int, error)
 }
 
+// ErrInvalidPassword - пароль не удовлетворяет требованиям
+var ErrInvalidPassword = errors.New("password must be between 8 and 72 bytes")
+
+// Ограничения длины пароля (72 байта - предел bcrypt)
+const (
+	minPasswordLen = 8
+	maxPasswordLen = 72
+)
+
 type UserByClientIDResponse struct {
 	UserID   string
 	Username string
@@ -26,20 +38,26 @@ type UserByClientIDResponse struct {
 type userService struct {
 	repo       repository.UserRepository
 	userClient repository.UserClientRepository
+	hasher     auth.PasswordHasher
 }
 
-func NewUserService(repo repository.UserRepository, userClient repository.UserClientRepository) UserService {
+func NewUserService(
+	repo repository.UserRepository,
+	userClient repository.UserClientRepository,
+	hasher auth.PasswordHasher,
+) UserService {
 	return &userService{
 		repo:       repo,
 		userClient: userClient,
+		hasher:     hasher,
 	}
 }
 
-func (s *userService) GetUser(ctx context.Context, id string) (interface{}, error) {
+func (s *userService) GetUser(ctx context.Context, id string) (*models.User, error) {
 	return s.repo.GetByID(ctx, id)
 }
 
-func (s *userService) GetUserByUsername(ctx context.Context, username string) (interface{}, error) {
+func (s *userService) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
 	return s.repo.GetByUsername(ctx, username)
 }
 
@@ -56,33 +74,38 @@ func (s *userService) GetUserByClientID(ctx context.Context, clientID string) (*
 		return nil, err
 	}
 
-	// TODO: преобразовать user в нормальную структуру когда репозиторий будет готов
-	// Сейчас это map[string]interface{}, нужно будет изменить на models.User
-	userMap, ok := user.(map[string]interface{})
-	if !ok {
-		return nil, nil
-	}
-
 	return &UserByClientIDResponse{
-		UserID:   userClient.UserID,
-		Username: userMap["username"].(string),
-		IsActive: true,             // TODO: get from user
-		Roles:    []string{"user"}, // TODO: get from user
+		UserID:   user.ID,
+		Username: user.Username,
+		IsActive: isUserActive(user),
+		Roles:    user.Roles,
 	}, nil
 }
 
-func (s *userService) CreateUser(ctx context.Context, user interface{}) error {
+// CreateUser хэширует пароль текущим алгоритмом и создает пользователя
+func (s *userService) CreateUser(ctx context.Context, user *models.User, password string) error {
+	if len(password) < minPasswordLen || len(password) > maxPasswordLen {
+		return ErrInvalidPassword
+	}
+
+	hash, err := s.hasher.Hash(password)
+	if err != nil {
+		return err
+	}
+	user.PasswordHash = hash
+
 	return s.repo.Create(ctx, user)
 }
 
-func (s *userService) UpdateUser(ctx context.Context, id string, user interface{}) error {
-	return s.repo.Update(ctx, id, user)
+func (s *userService) UpdateUser(ctx context.Context, id string, user *models.User) error {
+	user.ID = id
+	return s.repo.Update(ctx, user)
 }
 
 func (s *userService) DeleteUser(ctx context.Context, id string) error {
 	return s.repo.Delete(ctx, id)
 }
 
-func (s *userService) ListUsers(ctx context.Context, page, limit int, filter string) ([]interface{}, int, error) {
+func (s *userService) ListUsers(ctx context.Context, page, limit int, filter string) ([]*models.User, int, error) {
 	return s.repo.List(ctx, page, limit, filter)
 }
@@ -0,0 +1,120 @@
+package throttle
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore хранит счетчики в памяти процесса. Подходит для одного экземпляра и тестов:
+// при нескольких репликах у каждой свои счетчики
+type MemoryStore struct {
+	mu        sync.Mutex
+	entries   map[string]*memoryEntry
+	lastSweep time.Time
+}
+
+type memoryEntry struct {
+	failures     int
+	expiresAt    time.Time
+	blockedUntil time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		entries: make(map[string]*memoryEntry),
+	}
+}
+
+func (s *MemoryStore) Get(_ context.Context, key string) (State, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e := s.entry(key, time.Now())
+	if e == nil {
+		return State{}, nil
+	}
+	return State{Failures: e.failures, BlockedUntil: e.blockedUntil}, nil
+}
+
+func (s *MemoryStore) Reserve(_ context.Context, key string, window time.Duration, delays []time.Duration) (State, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now, window)
+
+	e := s.entry(key, now)
+	if e == nil {
+		e = &memoryEntry{}
+		s.entries[key] = e
+	}
+	if e.blockedUntil.After(now) {
+		return State{Failures: e.failures, BlockedUntil: e.blockedUntil}, false, nil
+	}
+
+	e.failures++
+	e.expiresAt = now.Add(window)
+	if delay := delays[min(e.failures, len(delays))-1]; delay > 0 {
+		// Счетчик живет не меньше блокировки, иначе блокировка по лимиту
+		// превратится в обычную задержку
+		e.blockedUntil = now.Add(delay)
+		if e.blockedUntil.After(e.expiresAt) {
+			e.expiresAt = e.blockedUntil
+		}
+	}
+
+	return State{Failures: e.failures, BlockedUntil: e.blockedUntil}, true, nil
+}
+
+func (s *MemoryStore) Refund(_ context.Context, key string, blockedUntil time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e := s.entry(key, time.Now())
+	if e == nil {
+		return nil
+	}
+	if e.failures > 0 {
+		e.failures--
+	}
+	if !blockedUntil.IsZero() && e.blockedUntil.Equal(blockedUntil) {
+		e.blockedUntil = time.Time{}
+	}
+	return nil
+}
+
+func (s *MemoryStore) Reset(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
+
+// entry возвращает неистекшую запись (вызывается под mu)
+func (s *MemoryStore) entry(key string, now time.Time) *memoryEntry {
+	e, ok := s.entries[key]
+	if !ok {
+		return nil
+	}
+	if !now.Before(e.expiresAt) {
+		delete(s.entries, key)
+		return nil
+	}
+	return e
+}
+
+// sweep удаляет истекшие записи не чаще раза в window (вызывается под mu)
+func (s *MemoryStore) sweep(now time.Time, window time.Duration) {
+	if now.Sub(s.lastSweep) < window {
+		return
+	}
+	s.lastSweep = now
+
+	for key, e := range s.entries {
+		if !now.Before(e.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+}
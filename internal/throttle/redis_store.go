@@ -0,0 +1,118 @@
+package throttle
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisKeyPrefix - пространство ключей счетчиков в Redis
+const redisKeyPrefix = "user-service:login:"
+
+// RedisStore хранит счетчики в Redis, поэтому лимиты общие для всех реплик.
+// Для каждого ключа используются два значения: <key>:failures (счетчик попыток с TTL окна)
+// и <key>:blocked (время окончания блокировки в unix ms с TTL до этого момента)
+type RedisStore struct {
+	client redis.UniversalClient
+}
+
+func NewRedisStore(client redis.UniversalClient) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+func (s *RedisStore) Get(ctx context.Context, key string) (State, error) {
+	values, err := s.client.MGet(ctx, failuresKey(key), blockedKey(key)).Result()
+	if err != nil {
+		return State{}, err
+	}
+
+	var state State
+	if v, ok := values[0].(string); ok {
+		state.Failures, _ = strconv.Atoi(v)
+	}
+	if v, ok := values[1].(string); ok {
+		if ms, err := strconv.ParseInt(v, 10, 64); err == nil {
+			state.BlockedUntil = time.UnixMilli(ms)
+		}
+	}
+	return state, nil
+}
+
+// reserveScript проверяет блокировку, увеличивает счетчик и блокирует ключ на задержку
+// для нового значения счетчика за один шаг. ARGV: текущее время в unix ms, окно счетчика
+// в ms и задержки после 1..n ошибок в ms. Счетчик живет не меньше блокировки, иначе
+// блокировка по лимиту превратится в обычную задержку
+var reserveScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local blocked = tonumber(redis.call('GET', KEYS[2]) or '0')
+if blocked > now then
+	return {tonumber(redis.call('GET', KEYS[1]) or '0'), blocked, 0}
+end
+
+local failures = redis.call('INCR', KEYS[1])
+local ttl = tonumber(ARGV[2])
+local delay = tonumber(ARGV[2 + math.min(failures, #ARGV - 2)])
+if delay > 0 then
+	blocked = now + delay
+	redis.call('SET', KEYS[2], blocked, 'PX', delay)
+	if delay > ttl then
+		ttl = delay
+	end
+end
+redis.call('PEXPIRE', KEYS[1], ttl)
+return {failures, blocked, 1}
+`)
+
+func (s *RedisStore) Reserve(ctx context.Context, key string, window time.Duration, delays []time.Duration) (State, bool, error) {
+	args := make([]interface{}, 0, len(delays)+2)
+	args = append(args, time.Now().UnixMilli(), window.Milliseconds())
+	for _, delay := range delays {
+		args = append(args, delay.Milliseconds())
+	}
+
+	result, err := reserveScript.Run(ctx, s.client, []string{failuresKey(key), blockedKey(key)}, args...).Int64Slice()
+	if err != nil {
+		return State{}, false, err
+	}
+
+	state := State{Failures: int(result[0])}
+	if result[1] > 0 {
+		state.BlockedUntil = time.UnixMilli(result[1])
+	}
+	return state, result[2] == 1, nil
+}
+
+// refundScript уменьшает счетчик, не меняя его TTL и не уходя ниже нуля, и снимает
+// блокировку, если она не менялась с возвращаемой попытки. ARGV[1] - окончание
+// блокировки этой попытки в unix ms (0 - попытка не блокировала ключ)
+var refundScript = redis.NewScript(`
+if tonumber(redis.call('GET', KEYS[1]) or '0') > 0 then
+	redis.call('DECR', KEYS[1])
+end
+if ARGV[1] ~= '0' and redis.call('GET', KEYS[2]) == ARGV[1] then
+	redis.call('DEL', KEYS[2])
+end
+return 1
+`)
+
+func (s *RedisStore) Refund(ctx context.Context, key string, blockedUntil time.Time) error {
+	var until int64
+	if !blockedUntil.IsZero() {
+		until = blockedUntil.UnixMilli()
+	}
+	return refundScript.Run(ctx, s.client, []string{failuresKey(key), blockedKey(key)}, until).Err()
+}
+
+func (s *RedisStore) Reset(ctx context.Context, key string) error {
+	return s.client.Del(ctx, failuresKey(key), blockedKey(key)).Err()
+}
+
+func failuresKey(key string) string {
+	return redisKeyPrefix + key + ":failures"
+}
+
+func blockedKey(key string) string {
+	return redisKeyPrefix + key + ":blocked"
+}
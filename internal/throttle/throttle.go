@@ -0,0 +1,253 @@
+// Package throttle ограничивает перебор паролей: считает неудачные попытки входа
+// по username и по IP, применяет экспоненциальную задержку и временную блокировку
+package throttle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/haqury/user-service/internal/config"
+)
+
+var (
+	// ErrTooManyAttempts - слишком частые попытки, следующая разрешена после задержки
+	ErrTooManyAttempts = errors.New("too many login attempts")
+
+	// ErrLockedOut - превышен лимит неудачных попыток, вход временно заблокирован
+	ErrLockedOut = errors.New("login temporarily locked")
+)
+
+// ThrottledError - отказ во входе с указанием, когда можно повторить.
+// Reason - ErrTooManyAttempts или ErrLockedOut (проверяется через errors.Is)
+type ThrottledError struct {
+	Reason     error
+	RetryAfter time.Duration
+}
+
+func (e *ThrottledError) Error() string {
+	return fmt.Sprintf("%v, retry after %s", e.Reason, e.RetryAfter.Round(time.Second))
+}
+
+func (e *ThrottledError) Unwrap() error {
+	return e.Reason
+}
+
+// State - состояние счетчика по ключу
+type State struct {
+	Failures     int
+	BlockedUntil time.Time
+}
+
+// Store хранит счетчики попыток. Реализации: MemoryStore (один экземпляр, тесты)
+// и RedisStore (общий для всех реплик)
+type Store interface {
+	Get(ctx context.Context, key string) (State, error)
+	// Reserve атомарно учитывает попытку по ключу. Если ключ заблокирован, попытка
+	// не учитывается: возвращается состояние ключа и false. Иначе счетчик увеличивается
+	// (он сбрасывается через window после последней попытки) и ключ блокируется на
+	// delays[failures-1]; для счетчика больше len(delays) - на последний элемент
+	Reserve(ctx context.Context, key string, window time.Duration, delays []time.Duration) (State, bool, error)
+	// Refund возвращает попытку, учтенную Reserve, и снимает блокировку, если она
+	// не менялась с этой попытки (BlockedUntil совпадает с blockedUntil)
+	Refund(ctx context.Context, key string, blockedUntil time.Time) error
+	Reset(ctx context.Context, key string) error
+}
+
+// LoginThrottler применяет политику config.LoginThrottleConfig к попыткам входа.
+// Каждая попытка считается неудачной с момента резервирования, до проверки пароля:
+// иначе параллельные попытки проходили бы проверку раньше, чем учтена хотя бы одна ошибка
+type LoginThrottler struct {
+	store  Store
+	policy config.LoginThrottleConfig
+
+	// userDelays и ipDelays - задержка после n-й ошибки (индекс n-1) для ключей username и IP
+	userDelays []time.Duration
+	ipDelays   []time.Duration
+}
+
+func NewLoginThrottler(store Store, policy config.LoginThrottleConfig) *LoginThrottler {
+	t := &LoginThrottler{
+		store:  store,
+		policy: policy,
+	}
+	t.userDelays = t.schedule(policy.MaxUserFailures)
+	t.ipDelays = t.schedule(policy.MaxIPFailures)
+	return t
+}
+
+// Attempt - попытка входа, учтенная Reserve. Завершается Success или Refund;
+// без них попытка остается неудачной
+type Attempt struct {
+	username string
+	reserved []reservation
+}
+
+// reservation - учтенная попытка по ключу и блокировка, выставленная ею
+type reservation struct {
+	key          string
+	blockedUntil time.Time
+}
+
+// Reserve учитывает попытку входа как неудачную до проверки пароля: после FreeAttempts
+// ошибок каждая следующая удваивает задержку (до MaxDelay), после лимита ключ блокируется
+// на LockoutDuration. Возвращает *ThrottledError, если username или IP заблокированы -
+// тогда попытка не учитывается
+func (t *LoginThrottler) Reserve(ctx context.Context, username, ip string) (*Attempt, error) {
+	keys := t.keys(username, ip)
+
+	// Предварительная проверка: отклоненная попытка не должна учитываться ни по одному ключу
+	now := time.Now()
+	var throttled *ThrottledError
+	for _, k := range keys {
+		state, err := t.store.Get(ctx, k.key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get login attempts: %w", err)
+		}
+		throttled = worse(throttled, t.throttled(state, k.limit, now))
+	}
+	if throttled != nil {
+		return nil, throttled
+	}
+
+	attempt := &Attempt{username: username, reserved: make([]reservation, 0, len(keys))}
+	for _, k := range keys {
+		state, ok, err := t.store.Reserve(ctx, k.key, t.policy.Window, k.delays)
+		if err == nil && ok {
+			attempt.reserved = append(attempt.reserved, reservation{key: k.key, blockedUntil: state.BlockedUntil})
+			continue
+		}
+
+		// Ключ успели заблокировать параллельные попытки: возвращаем уже учтенные
+		if refundErr := t.refund(ctx, attempt.reserved); refundErr != nil {
+			log.Printf("Failed to refund login attempt: %v", refundErr)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to reserve login attempt: %w", err)
+		}
+		if throttled := t.throttled(state, k.limit, time.Now()); throttled != nil {
+			return nil, throttled
+		}
+		return nil, &ThrottledError{Reason: ErrTooManyAttempts}
+	}
+	return attempt, nil
+}
+
+// Success завершает попытку входом: сбрасывает счетчик username и возвращает попытку
+// остальным ключам. Счетчик IP не сбрасывается, иначе перебор чужих аккаунтов можно было
+// бы обнулять входом в свой
+func (t *LoginThrottler) Success(ctx context.Context, attempt *Attempt) error {
+	if err := t.store.Reset(ctx, userKey(attempt.username)); err != nil {
+		return fmt.Errorf("failed to reset login attempts: %w", err)
+	}
+
+	rest := make([]reservation, 0, len(attempt.reserved))
+	for _, r := range attempt.reserved {
+		if r.key != userKey(attempt.username) {
+			rest = append(rest, r)
+		}
+	}
+	if err := t.refund(ctx, rest); err != nil {
+		return fmt.Errorf("failed to refund login attempt: %w", err)
+	}
+	return nil
+}
+
+// Refund возвращает попытку, которая не была проверкой пароля (например, при ошибке БД)
+func (t *LoginThrottler) Refund(ctx context.Context, attempt *Attempt) error {
+	if err := t.refund(ctx, attempt.reserved); err != nil {
+		return fmt.Errorf("failed to refund login attempt: %w", err)
+	}
+	return nil
+}
+
+func (t *LoginThrottler) refund(ctx context.Context, reserved []reservation) error {
+	var errs []error
+	for _, r := range reserved {
+		if err := t.store.Refund(ctx, r.key, r.blockedUntil); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// throttled возвращает отказ, если ключ заблокирован в момент now
+func (t *LoginThrottler) throttled(state State, limit int, now time.Time) *ThrottledError {
+	if !state.BlockedUntil.After(now) {
+		return nil
+	}
+
+	reason := ErrTooManyAttempts
+	if state.Failures >= limit {
+		reason = ErrLockedOut
+	}
+	return &ThrottledError{Reason: reason, RetryAfter: state.BlockedUntil.Sub(now)}
+}
+
+// worse выбирает отказ для ответа: блокировка важнее задержки, из одинаковых причин -
+// самая долгая
+func worse(a, b *ThrottledError) *ThrottledError {
+	switch {
+	case a == nil:
+		return b
+	case b == nil:
+		return a
+	case b.Reason == ErrLockedOut && a.Reason != ErrLockedOut:
+		return b
+	case b.Reason == a.Reason && b.RetryAfter > a.RetryAfter:
+		return b
+	}
+	return a
+}
+
+// schedule возвращает задержки после 1..limit ошибок
+func (t *LoginThrottler) schedule(limit int) []time.Duration {
+	delays := make([]time.Duration, max(limit, 1))
+	for i := range delays {
+		delays[i] = t.delay(i+1, limit)
+	}
+	return delays
+}
+
+func (t *LoginThrottler) delay(failures, limit int) time.Duration {
+	if failures >= limit {
+		return t.policy.LockoutDuration
+	}
+	if failures <= t.policy.FreeAttempts {
+		return 0
+	}
+
+	delay := t.policy.BaseDelay
+	for i := t.policy.FreeAttempts + 1; i < failures; i++ {
+		delay *= 2
+		if delay >= t.policy.MaxDelay {
+			return t.policy.MaxDelay
+		}
+	}
+	return delay
+}
+
+type throttleKey struct {
+	key    string
+	limit  int
+	delays []time.Duration
+}
+
+func (t *LoginThrottler) keys(username, ip string) []throttleKey {
+	keys := []throttleKey{{key: userKey(username), limit: t.policy.MaxUserFailures, delays: t.userDelays}}
+	if ip != "" {
+		keys = append(keys, throttleKey{key: ipKey(ip), limit: t.policy.MaxIPFailures, delays: t.ipDelays})
+	}
+	return keys
+}
+
+func userKey(username string) string {
+	return "user:" + strings.ToLower(username)
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
@@ -0,0 +1,272 @@
+package throttle
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/haqury/user-service/internal/config"
+)
+
+func TestDelay(t *testing.T) {
+	throttler := NewLoginThrottler(NewMemoryStore(), config.LoginThrottleConfig{
+		FreeAttempts:    3,
+		BaseDelay:       time.Second,
+		MaxDelay:        10 * time.Second,
+		LockoutDuration: time.Hour,
+	})
+
+	tests := []struct {
+		name     string
+		failures int
+		limit    int
+		want     time.Duration
+	}{
+		{name: "no failures", failures: 0, limit: 10, want: 0},
+		{name: "last free attempt", failures: 3, limit: 10, want: 0},
+		{name: "first delayed attempt", failures: 4, limit: 10, want: time.Second},
+		{name: "delay doubles", failures: 5, limit: 10, want: 2 * time.Second},
+		{name: "delay doubles again", failures: 7, limit: 10, want: 8 * time.Second},
+		{name: "delay capped", failures: 8, limit: 10, want: 10 * time.Second},
+		{name: "stays capped", failures: 9, limit: 10, want: 10 * time.Second},
+		{name: "limit locks out", failures: 10, limit: 10, want: time.Hour},
+		{name: "over limit locks out", failures: 12, limit: 10, want: time.Hour},
+		{name: "limit within free attempts", failures: 2, limit: 2, want: time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := throttler.delay(tt.failures, tt.limit); got != tt.want {
+				t.Errorf("delay(%d, %d) = %s, want %s", tt.failures, tt.limit, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReserveTransitions(t *testing.T) {
+	policy := config.LoginThrottleConfig{
+		Window:          time.Hour,
+		FreeAttempts:    2,
+		BaseDelay:       50 * time.Millisecond,
+		MaxDelay:        100 * time.Millisecond,
+		MaxUserFailures: 5,
+		MaxIPFailures:   100,
+		LockoutDuration: time.Hour,
+	}
+
+	type step struct {
+		name string
+		wait time.Duration
+		// success - попытка перед шагом завершилась входом
+		success bool
+		wantErr error
+		// maxRetry - верхняя граница RetryAfter отказа
+		maxRetry time.Duration
+	}
+
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "backoff then lockout",
+			steps: []step{
+				{name: "free 1"},
+				{name: "free 2"},
+				{name: "first delayed"},
+				{name: "inside delay", wantErr: ErrTooManyAttempts, maxRetry: 50 * time.Millisecond},
+				{name: "after delay", wait: 60 * time.Millisecond},
+				{name: "inside doubled delay", wantErr: ErrTooManyAttempts, maxRetry: 100 * time.Millisecond},
+				{name: "reaches limit", wait: 110 * time.Millisecond},
+				{name: "locked out", wantErr: ErrLockedOut, maxRetry: time.Hour},
+				{name: "still locked out", wait: 110 * time.Millisecond, wantErr: ErrLockedOut, maxRetry: time.Hour},
+			},
+		},
+		{
+			name: "success resets user counter",
+			steps: []step{
+				{name: "free 1"},
+				{name: "free 2"},
+				{name: "correct password", success: true},
+				{name: "free again"},
+				{name: "free again 2"},
+				{name: "delayed again"},
+				{name: "inside delay", wantErr: ErrTooManyAttempts, maxRetry: 50 * time.Millisecond},
+			},
+		},
+		{
+			name: "success lifts lockout",
+			steps: []step{
+				{name: "free 1"},
+				{name: "free 2"},
+				{name: "delayed"},
+				{name: "after delay", wait: 60 * time.Millisecond},
+				{name: "reaches limit", wait: 110 * time.Millisecond, success: true},
+				{name: "unlocked"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			throttler := NewLoginThrottler(NewMemoryStore(), policy)
+
+			for _, s := range tt.steps {
+				time.Sleep(s.wait)
+
+				attempt, err := throttler.Reserve(ctx, "alice", "")
+				if s.wantErr == nil {
+					if err != nil {
+						t.Fatalf("%s: Reserve() error = %v", s.name, err)
+					}
+					if s.success {
+						if err := throttler.Success(ctx, attempt); err != nil {
+							t.Fatalf("%s: Success() error = %v", s.name, err)
+						}
+					}
+					continue
+				}
+
+				var throttled *ThrottledError
+				if !errors.As(err, &throttled) || !errors.Is(err, s.wantErr) {
+					t.Fatalf("%s: Reserve() error = %v, want %v", s.name, err, s.wantErr)
+				}
+				if throttled.RetryAfter <= 0 || throttled.RetryAfter > s.maxRetry {
+					t.Fatalf("%s: RetryAfter = %s, want (0, %s]", s.name, throttled.RetryAfter, s.maxRetry)
+				}
+			}
+		})
+	}
+}
+
+func TestReserveIPCounter(t *testing.T) {
+	ctx := context.Background()
+	throttler := NewLoginThrottler(NewMemoryStore(), config.LoginThrottleConfig{
+		Window:          time.Hour,
+		FreeAttempts:    100,
+		MaxUserFailures: 100,
+		MaxIPFailures:   3,
+		LockoutDuration: time.Hour,
+	})
+
+	// Две неудачные попытки на разные аккаунты, затем вход в свой: попытка входа
+	// возвращается, но ошибки по IP не обнуляются
+	for _, username := range []string{"alice", "bob"} {
+		if _, err := throttler.Reserve(ctx, username, "10.0.0.1"); err != nil {
+			t.Fatalf("Reserve(%s) error = %v", username, err)
+		}
+	}
+	// Вход в свой аккаунт - третья попытка с IP: ее блокировка снимается вместе с ней
+	attempt, err := throttler.Reserve(ctx, "mallory", "10.0.0.1")
+	if err != nil {
+		t.Fatalf("Reserve(mallory) error = %v", err)
+	}
+	if err := throttler.Success(ctx, attempt); err != nil {
+		t.Fatalf("Success() error = %v", err)
+	}
+
+	// Третья ошибка с IP достигает лимита, блокировка действует и для других аккаунтов
+	if _, err := throttler.Reserve(ctx, "carol", "10.0.0.1"); err != nil {
+		t.Fatalf("Reserve(carol) error = %v", err)
+	}
+	_, err = throttler.Reserve(ctx, "dave", "10.0.0.1")
+	if !errors.Is(err, ErrLockedOut) {
+		t.Fatalf("Reserve(dave) error = %v, want %v", err, ErrLockedOut)
+	}
+
+	// Отклоненная попытка не учитывается по username
+	state, err := throttler.store.Get(ctx, userKey("dave"))
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if state.Failures != 0 {
+		t.Errorf("dave failures = %d, want 0", state.Failures)
+	}
+
+	// Попытки с другого IP не заблокированы
+	if _, err := throttler.Reserve(ctx, "dave", "10.0.0.2"); err != nil {
+		t.Errorf("Reserve(dave, other ip) error = %v", err)
+	}
+}
+
+func TestReserveRefund(t *testing.T) {
+	ctx := context.Background()
+	throttler := NewLoginThrottler(NewMemoryStore(), config.LoginThrottleConfig{
+		Window:          time.Hour,
+		FreeAttempts:    1,
+		BaseDelay:       time.Hour,
+		MaxDelay:        time.Hour,
+		MaxUserFailures: 10,
+		MaxIPFailures:   10,
+		LockoutDuration: time.Hour,
+	})
+
+	// Возвращенные попытки (например, при ошибке БД) не приближают задержку
+	for i := 0; i < 5; i++ {
+		attempt, err := throttler.Reserve(ctx, "alice", "10.0.0.1")
+		if err != nil {
+			t.Fatalf("attempt %d: Reserve() error = %v", i+1, err)
+		}
+		if err := throttler.Refund(ctx, attempt); err != nil {
+			t.Fatalf("attempt %d: Refund() error = %v", i+1, err)
+		}
+	}
+
+	for _, key := range []string{userKey("alice"), ipKey("10.0.0.1")} {
+		state, err := throttler.store.Get(ctx, key)
+		if err != nil {
+			t.Fatalf("Get(%s) error = %v", key, err)
+		}
+		if state.Failures != 0 {
+			t.Errorf("%s failures = %d, want 0", key, state.Failures)
+		}
+	}
+}
+
+func TestReserveConcurrent(t *testing.T) {
+	const (
+		freeAttempts = 3
+		attempts     = 50
+	)
+	throttler := NewLoginThrottler(NewMemoryStore(), config.LoginThrottleConfig{
+		Window:          time.Hour,
+		FreeAttempts:    freeAttempts,
+		BaseDelay:       time.Hour,
+		MaxDelay:        time.Hour,
+		MaxUserFailures: 10,
+		MaxIPFailures:   10,
+		LockoutDuration: time.Hour,
+	})
+
+	// Параллельные попытки до проверки пароля: проходят только бесплатные и первая
+	// попытка с задержкой, остальные видят блокировку
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		allowed int
+	)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := throttler.Reserve(context.Background(), "alice", "10.0.0.1")
+			if err == nil {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+				return
+			}
+			if !errors.Is(err, ErrTooManyAttempts) {
+				t.Errorf("Reserve() error = %v, want %v", err, ErrTooManyAttempts)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if allowed != freeAttempts+1 {
+		t.Errorf("allowed %d of %d parallel attempts, want %d", allowed, attempts, freeAttempts+1)
+	}
+}
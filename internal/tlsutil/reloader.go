@@ -0,0 +1,285 @@
+// Package tlsutil собирает tls.Config для gRPC сервера, HTTP gateway и его соединения
+// с gRPC сервером из файлов сертификатов и перечитывает их при изменении без рестарта
+package tlsutil
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/haqury/user-service/internal/config"
+)
+
+// Reloader хранит текущие сертификаты и пулы CA. Все tls.Config, которые он выдает,
+// берут материал на каждом handshake, поэтому перечитанные файлы применяются
+// к новым соединениям сразу
+type Reloader struct {
+	cfg config.TLSConfig
+
+	mu         sync.RWMutex
+	serverCert *tls.Certificate
+	clientCert *tls.Certificate
+	clientCAs  *x509.CertPool // проверка клиентских сертификатов (mTLS)
+	serverCAs  *x509.CertPool // проверка сертификата gRPC сервера при dial gateway; nil - системные
+	modTimes   map[string]time.Time
+}
+
+// NewReloader загружает файлы из конфигурации; ошибка загрузки при старте фатальна
+func NewReloader(cfg config.TLSConfig) (*Reloader, error) {
+	if cfg.CertFile == "" || cfg.KeyFile == "" {
+		return nil, errors.New("tls cert_file and key_file are required")
+	}
+	if cfg.ClientAuth == "" {
+		cfg.ClientAuth = config.TLSClientAuthNone
+	}
+	if _, err := clientAuthType(cfg.ClientAuth); err != nil {
+		return nil, err
+	}
+	if cfg.ClientAuth != config.TLSClientAuthNone && cfg.ClientCAFile == "" {
+		return nil, errors.New("tls client_ca_file is required when client_auth is enabled")
+	}
+
+	r := &Reloader{cfg: cfg}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Run проверяет время изменения файлов каждые interval и перечитывает их.
+// При ошибке загрузки остаются предыдущие сертификаты
+func (r *Reloader) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !r.changed() {
+				continue
+			}
+			if err := r.reload(); err != nil {
+				log.Printf("Failed to reload TLS certificates, keeping previous: %v", err)
+				continue
+			}
+			log.Println("TLS certificates reloaded")
+		}
+	}
+}
+
+// GRPCServerConfig - конфигурация gRPC сервера с проверкой клиентских сертификатов
+// согласно client_auth
+func (r *Reloader) GRPCServerConfig() *tls.Config {
+	clientAuth, _ := clientAuthType(r.cfg.ClientAuth)
+
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			r.mu.RLock()
+			defer r.mu.RUnlock()
+
+			return &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*r.serverCert},
+				ClientAuth:   clientAuth,
+				ClientCAs:    r.clientCAs,
+				NextProtos:   []string{"h2"},
+			}, nil
+		},
+	}
+}
+
+// HTTPServerConfig - конфигурация HTTP gateway. Клиентские сертификаты не запрашиваются:
+// внешние клиенты аутентифицируются токенами
+func (r *Reloader) HTTPServerConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			r.mu.RLock()
+			defer r.mu.RUnlock()
+			return r.serverCert, nil
+		},
+	}
+}
+
+// GatewayClientConfig - конфигурация соединения gateway с gRPC сервером по адресу addr.
+// Клиентский сертификат предъявляется, если gRPC сервер проверяет сертификаты.
+// Стандартная проверка сертификата сервера заменена собственной в VerifyConnection,
+// чтобы пул CA тоже перечитывался без рестарта
+func (r *Reloader) GatewayClientConfig(addr string) *tls.Config {
+	serverName := r.cfg.ServerName
+	if serverName == "" {
+		serverName = hostOf(addr)
+	}
+
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: serverName,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			r.mu.RLock()
+			defer r.mu.RUnlock()
+			if r.clientCert == nil {
+				return &tls.Certificate{}, nil
+			}
+			return r.clientCert, nil
+		},
+		InsecureSkipVerify: true, // проверка выполняется в VerifyConnection
+		VerifyConnection: func(state tls.ConnectionState) error {
+			return r.verifyServer(state, serverName)
+		},
+	}
+}
+
+func (r *Reloader) verifyServer(state tls.ConnectionState, serverName string) error {
+	if len(state.PeerCertificates) == 0 {
+		return errors.New("tls: server did not present a certificate")
+	}
+
+	r.mu.RLock()
+	roots := r.serverCAs
+	r.mu.RUnlock()
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range state.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	_, err := state.PeerCertificates[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		DNSName:       serverName,
+	})
+	return err
+}
+
+// reload загружает все файлы и атомарно подменяет материал
+func (r *Reloader) reload() error {
+	modTimes := r.statFiles()
+
+	serverCert, err := tls.LoadX509KeyPair(r.cfg.CertFile, r.cfg.KeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load server certificate: %w", err)
+	}
+
+	var clientCert *tls.Certificate
+	if r.cfg.ClientAuth != config.TLSClientAuthNone {
+		// Без отдельного сертификата gateway предъявляет сертификат сервера
+		// (он должен допускать использование clientAuth)
+		certFile, keyFile := r.cfg.GatewayCertFile, r.cfg.GatewayKeyFile
+		if certFile == "" || keyFile == "" {
+			certFile, keyFile = r.cfg.CertFile, r.cfg.KeyFile
+		}
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return fmt.Errorf("failed to load gateway client certificate: %w", err)
+		}
+		clientCert = &cert
+	}
+
+	clientCAs, err := loadCertPool(r.cfg.ClientCAFile)
+	if err != nil {
+		return fmt.Errorf("failed to load client CA: %w", err)
+	}
+	serverCAs, err := loadCertPool(r.cfg.ServerCAFile)
+	if err != nil {
+		return fmt.Errorf("failed to load server CA: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.serverCert = &serverCert
+	r.clientCert = clientCert
+	r.clientCAs = clientCAs
+	r.serverCAs = serverCAs
+	r.modTimes = modTimes
+	return nil
+}
+
+// changed - время изменения какого-либо файла отличается от загруженного
+func (r *Reloader) changed() bool {
+	current := r.statFiles()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(current) != len(r.modTimes) {
+		return true
+	}
+	for file, modTime := range current {
+		if !r.modTimes[file].Equal(modTime) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Reloader) statFiles() map[string]time.Time {
+	files := []string{
+		r.cfg.CertFile, r.cfg.KeyFile, r.cfg.ClientCAFile, r.cfg.ServerCAFile,
+		r.cfg.GatewayCertFile, r.cfg.GatewayKeyFile,
+	}
+
+	modTimes := make(map[string]time.Time, len(files))
+	for _, file := range files {
+		if file == "" {
+			continue
+		}
+		if info, err := os.Stat(file); err == nil {
+			modTimes[file] = info.ModTime()
+		}
+	}
+	return modTimes
+}
+
+// loadCertPool читает PEM с сертификатами CA; пустой путь - nil (системные корни)
+func loadCertPool(file string) (*x509.CertPool, error) {
+	if file == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in %s", file)
+	}
+	return pool, nil
+}
+
+func clientAuthType(mode string) (tls.ClientAuthType, error) {
+	switch mode {
+	case config.TLSClientAuthNone:
+		return tls.NoClientCert, nil
+	case config.TLSClientAuthOptional:
+		return tls.VerifyClientCertIfGiven, nil
+	case config.TLSClientAuthRequire:
+		return tls.RequireAndVerifyClientCert, nil
+	default:
+		return tls.NoClientCert, fmt.Errorf("unsupported tls client_auth: %s", mode)
+	}
+}
+
+func hostOf(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
@@ -0,0 +1,177 @@
+// Package streamticket выпускает и проверяет билеты на подключение потока к video-service.
+//
+// Билет - JWT (HS256), который user-service выдает клиенту вместе с конфигурацией стриминга.
+// В нем записаны пользователь, клиент, назначенный инстанс video-service и лимиты потока.
+// Инстанс проверяет билет без обращения к user-service: подпись общим секретом, срок
+// действия и то, что клиент направлен именно на него, после чего применяет лимиты:
+//
+//	verifier, err := streamticket.NewVerifier(instanceID, streamticket.Key{ID: "2026-10", Secret: secret})
+//	claims, err := verifier.Verify(ticket)
+//	if !claims.AllowsBitrate(requestedKbps) { ... }
+package streamticket
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Issuer - издатель билетов
+const Issuer = "user-service"
+
+// Audience - получатель билетов
+const Audience = "video-service"
+
+// MinSecretLen - минимальная длина секрета HMAC в байтах (256 бит)
+const MinSecretLen = 32
+
+var (
+	// ErrInvalidTicket - подпись, срок действия или claims билета не прошли проверку
+	ErrInvalidTicket = errors.New("invalid stream ticket")
+
+	// ErrWrongInstance - билет выдан для другого инстанса video-service
+	ErrWrongInstance = errors.New("stream ticket issued for another instance")
+
+	// ErrWeakSecret - секрет короче MinSecretLen
+	ErrWeakSecret = fmt.Errorf("stream ticket secret must be at least %d bytes", MinSecretLen)
+)
+
+// Claims - содержимое билета. Нулевые лимиты означают отсутствие ограничения
+type Claims struct {
+	UserID        string `json:"uid"`
+	ClientID      string `json:"cid"`
+	InstanceID    string `json:"iid"`
+	MaxBitrate    int32  `json:"max_bitrate,omitempty"`
+	MaxResolution int32  `json:"max_resolution,omitempty"`
+	Codec         string `json:"codec,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// AllowsBitrate - битрейт не превышает лимит билета
+func (c *Claims) AllowsBitrate(bitrate int32) bool {
+	return c.MaxBitrate <= 0 || bitrate <= c.MaxBitrate
+}
+
+// AllowsResolution - разрешение (высота кадра, например 1080) не превышает лимит билета
+func (c *Claims) AllowsResolution(resolution int32) bool {
+	return c.MaxResolution <= 0 || resolution <= c.MaxResolution
+}
+
+// Key - секрет HMAC. ID попадает в заголовок kid и позволяет менять секрет:
+// проверяющая сторона держит старый и новый ключи, пока действуют выданные билеты
+type Key struct {
+	ID     string
+	Secret []byte
+}
+
+// Signer выпускает билеты
+type Signer struct {
+	key Key
+	ttl time.Duration
+}
+
+func NewSigner(key Key, ttl time.Duration) (*Signer, error) {
+	if len(key.Secret) < MinSecretLen {
+		return nil, ErrWeakSecret
+	}
+	if ttl <= 0 {
+		return nil, errors.New("stream ticket ttl must be positive")
+	}
+	return &Signer{key: key, ttl: ttl}, nil
+}
+
+// Sign подписывает claims; заполняет iss/aud/sub/iat/nbf/exp и возвращает время истечения
+func (s *Signer) Sign(claims *Claims) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(s.ttl)
+
+	claims.Issuer = Issuer
+	claims.Audience = jwt.ClaimStrings{Audience}
+	claims.Subject = claims.UserID
+	claims.IssuedAt = jwt.NewNumericDate(now)
+	claims.NotBefore = jwt.NewNumericDate(now)
+	claims.ExpiresAt = jwt.NewNumericDate(expiresAt)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	if s.key.ID != "" {
+		token.Header["kid"] = s.key.ID
+	}
+
+	signed, err := token.SignedString(s.key.Secret)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to sign stream ticket: %w", err)
+	}
+
+	return signed, expiresAt, nil
+}
+
+// Verifier проверяет билеты на стороне инстанса video-service
+type Verifier struct {
+	instanceID string
+	keys       map[string][]byte
+	leeway     time.Duration
+}
+
+// NewVerifier создает проверку для инстанса instanceID. Пустой instanceID отключает
+// проверку инстанса (например, для балансировщика перед группой инстансов)
+func NewVerifier(instanceID string, keys ...Key) (*Verifier, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("at least one stream ticket key is required")
+	}
+
+	v := &Verifier{
+		instanceID: instanceID,
+		keys:       make(map[string][]byte, len(keys)),
+		leeway:     5 * time.Second,
+	}
+	for _, key := range keys {
+		if len(key.Secret) < MinSecretLen {
+			return nil, ErrWeakSecret
+		}
+		if _, ok := v.keys[key.ID]; ok {
+			return nil, fmt.Errorf("duplicate stream ticket key id %q", key.ID)
+		}
+		v.keys[key.ID] = key.Secret
+	}
+	return v, nil
+}
+
+// WithLeeway задает допуск расхождения часов при проверке сроков (по умолчанию 5s)
+func (v *Verifier) WithLeeway(leeway time.Duration) *Verifier {
+	v.leeway = leeway
+	return v
+}
+
+// Verify проверяет подпись, срок действия, издателя, получателя и инстанс
+func (v *Verifier) Verify(ticket string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(ticket, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		secret, ok := v.keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown key id %q", kid)
+		}
+		return secret, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithIssuer(Issuer),
+		jwt.WithAudience(Audience),
+		jwt.WithLeeway(v.leeway),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTicket, err)
+	}
+
+	if claims.UserID == "" || claims.InstanceID == "" {
+		return nil, fmt.Errorf("%w: missing user or instance", ErrInvalidTicket)
+	}
+	if v.instanceID != "" && claims.InstanceID != v.instanceID {
+		return nil, ErrWrongInstance
+	}
+
+	return claims, nil
+}